@@ -0,0 +1,1101 @@
+package sst
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	arango "github.com/arangodb/go-driver"
+	"github.com/arangodb/go-driver/http"
+	"github.com/pkg/errors"
+)
+
+// maxUpsertAttempts bounds the attempts to upsert a document changed concurrently
+const maxUpsertAttempts = 16
+
+// arangoBackend stores Semantic Spacetime in an ArangoDB graph
+type arangoBackend struct {
+	client arango.Client
+	conn   arango.Connection
+	db     arango.Database
+	graph  arango.Graph
+	name   string
+
+	associations arango.Collection
+	events       arango.Collection
+	timelines    arango.Collection
+
+	// mu guards nodes, which node collections are added to and removed from
+	mu    sync.RWMutex
+	nodes map[string]arango.Collection
+
+	follows   arango.Collection
+	contains  arango.Collection
+	expresses arango.Collection
+	near      arango.Collection
+}
+
+// arangoDocument captures the ArangoDB _id of a node or link
+type arangoDocument struct {
+	ID string `json:"_id"`
+}
+
+// Open connects to ArangoDB and creates the database and graph if they do not
+// exist. The node collections designated by config are added to an existing graph.
+func (b *arangoBackend) Open(ctx context.Context, config *Config) error {
+	b.name = "semantic_spacetime"
+
+	conn, err := http.NewConnection(http.ConnectionConfig{
+		Endpoints: []string{config.URL},
+	})
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create ArangoDB connection")
+	}
+	b.conn = conn
+	client, err := arango.NewClient(arango.ClientConfig{
+		Connection:     b.conn,
+		Authentication: arango.BasicAuthentication(config.Username, config.Password),
+	})
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create ArangoDB client")
+	}
+	b.client = client
+
+	b.db, err = b.openDatabase(ctx, config.Name)
+	if err == databaseDoesNotExist {
+		b.db, err = b.createDatabase(ctx, config.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := b.db.GraphExists(ctx, b.name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		b.graph, err = b.db.Graph(ctx, b.name)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to open graph: %v", b.name)
+		}
+	} else {
+		b.graph, err = b.db.CreateGraph(ctx, b.name, &arango.CreateGraphOptions{
+			OrphanVertexCollections: []string{disconnectedCollection},
+			EdgeDefinitions: []arango.EdgeDefinition{
+				{Collection: "Near", From: config.NodeCollections, To: config.NodeCollections},
+				{Collection: "Follows", From: config.NodeCollections, To: config.NodeCollections},
+				{Collection: "Contains", From: config.NodeCollections, To: config.NodeCollections},
+				{Collection: "Expresses", From: config.NodeCollections, To: config.NodeCollections},
+			},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to create graph: %v", b.name)
+		}
+	}
+
+	b.nodes = make(map[string]arango.Collection)
+	cols, err := b.graph.VertexCollections(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to read vertex collections of graph: %v", b.name)
+	}
+	for _, col := range cols {
+		b.nodes[col.Name()] = col
+	}
+	err = b.addNodeCollections(ctx, config.NodeCollections)
+	if err != nil {
+		return err
+	}
+
+	b.near, _, err = b.graph.EdgeCollection(ctx, "Near")
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create Near vertex collection")
+	}
+	b.follows, _, err = b.graph.EdgeCollection(ctx, "Follows")
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create Follows vertex collection")
+	}
+	b.contains, _, err = b.graph.EdgeCollection(ctx, "Contains")
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create Contains vertex collection")
+	}
+	b.expresses, _, err = b.graph.EdgeCollection(ctx, "Expresses")
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to create Expresses vertex collection")
+	}
+
+	b.associations, err = b.documentCollection(ctx, "Associations")
+	if err != nil {
+		return err
+	}
+	b.timelines, err = b.documentCollection(ctx, "Timelines")
+	if err != nil {
+		return err
+	}
+	b.events, err = b.documentCollection(ctx, "Events")
+	if err != nil {
+		return err
+	}
+	_, _, err = b.events.EnsurePersistentIndex(ctx, []string{"timeline", "time"}, nil)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to index Events collection")
+	}
+
+	return nil
+}
+
+// Close is a noop, ArangoDB connections do not need to be released.
+func (b *arangoBackend) Close() error {
+	return nil
+}
+
+// UpsertNode idempotently inserts the node into the collection specified by
+// node.Prefix using a single atomic UPSERT
+func (b *arangoBackend) UpsertNode(ctx context.Context, node *Node) error {
+	nodes, err := b.collectionOf(node.Prefix)
+	if err != nil {
+		return err
+	}
+	update := "@node"
+	if node.Data == nil && node.Weight == 0.0 && node.Time == nil {
+		update = "{}" // Do not update the node if there is no data to enter
+		if node.Name != "" {
+			update = "{name: NOT_NULL(OLD.name, @node.name)}" // Only name nodes stored without name
+		}
+	}
+	err = b.upsert(
+		ctx,
+		"UPSERT {_key: @node._key} INSERT @node UPDATE "+update+" IN @@collection RETURN NEW",
+		map[string]interface{}{"@collection": nodes.Name(), "node": node},
+		nil,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to upsert node: %v", node)
+	}
+	return nil
+}
+
+// UpsertNodes idempotently inserts the nodes using multi-document operations per node collection
+func (b *arangoBackend) UpsertNodes(ctx context.Context, nodes []*Node) error {
+	errs := make(BatchError, len(nodes))
+	batches := make(map[string][]int)
+	for i, node := range nodes {
+		batches[node.Prefix] = append(batches[node.Prefix], i)
+	}
+	for prefix, batch := range batches {
+		col, err := b.collectionOf(prefix)
+		if err != nil {
+			for _, i := range batch {
+				errs[i] = err
+			}
+			continue
+		}
+		keys := make([]string, 0, len(batch))
+		for _, i := range batch {
+			keys = append(keys, nodes[i].Key)
+		}
+		upsertDocuments(ctx, col, keys, errs.slice(batch), func(j int, stored []byte) ([]byte, error) {
+			return upsertNodeDocument(stored, nodes[batch[j]])
+		})
+	}
+	return errs.orNil()
+}
+
+// ReadNode reads the node with the designated _id
+func (b *arangoBackend) ReadNode(ctx context.Context, id string) (*Node, error) {
+	kind, key := splitNodeID(id)
+	nodes, err := b.collectionOf(kind + "/")
+	if err != nil {
+		return nil, err
+	}
+	var node Node
+	_, err = nodes.ReadDocument(ctx, key, &node)
+	if arango.IsNotFound(err) {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no node for id: %v", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// RemoveNode removes the node with the designated _id if it exists
+func (b *arangoBackend) RemoveNode(ctx context.Context, id string) error {
+	kind, key := splitNodeID(id)
+	nodes, err := b.collectionOf(kind + "/")
+	if err != nil {
+		return err
+	}
+	_, err = nodes.RemoveDocument(ctx, key)
+	if err != nil && !arango.IsNotFound(err) {
+		return errors.Wrapf(err, "sst: failed to remove node: %v", id)
+	}
+	return nil
+}
+
+// ReadNodeCollections reads the names of the vertex collections of the graph
+func (b *arangoBackend) ReadNodeCollections(ctx context.Context) ([]string, error) {
+	cols, err := b.graph.VertexCollections(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read vertex collections of graph: %v", b.name)
+	}
+	names := make([]string, 0, len(cols))
+	for _, col := range cols {
+		names = append(names, col.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AddNodeCollection adds the named vertex collection to the from and to vertex
+// collections of the edge definitions of the graph, creating it if it does not exist
+func (b *arangoBackend) AddNodeCollection(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.addNodeCollections(ctx, []string{name})
+}
+
+// RemoveNodeCollection removes the named vertex collection from the edge
+// definitions of the graph, then removes it from the graph and drops it
+func (b *arangoBackend) RemoveNodeCollection(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	exists, err := b.graph.VertexCollectionExists(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to check %v vertex collection existence", name)
+	}
+	if !exists {
+		delete(b.nodes, name)
+		return nil
+	}
+	cols, constraints, err := b.graph.EdgeCollections(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to read edge definitions of graph: %v", b.name)
+	}
+	for i, col := range cols {
+		from, to := without(constraints[i].From, name), without(constraints[i].To, name)
+		if len(from) == len(constraints[i].From) && len(to) == len(constraints[i].To) {
+			continue
+		}
+		err := b.graph.SetVertexConstraints(ctx, col.Name(), arango.VertexConstraints{From: from, To: to})
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to remove %v from %v edge definition", name, col.Name())
+		}
+	}
+	// go-driver does not support removing vertex collections from a graph
+	req, err := b.conn.NewRequest("DELETE", path.Join("_db", url.PathEscape(b.db.Name()), "_api/gharial", url.PathEscape(b.name), "vertex", url.PathEscape(name)))
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to remove %v vertex collection", name)
+	}
+	req.SetQuery("dropCollection", "true")
+	resp, err := b.conn.Do(ctx, req)
+	if err == nil {
+		err = resp.CheckStatus(200, 202)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to remove %v vertex collection", name)
+	}
+	delete(b.nodes, name)
+	return nil
+}
+
+// addNodeCollections adds the named vertex collections to the from and to
+// vertex collections of the edge definitions of the graph, callers must hold the lock
+func (b *arangoBackend) addNodeCollections(ctx context.Context, names []string) error {
+	cols, constraints, err := b.graph.EdgeCollections(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to read edge definitions of graph: %v", b.name)
+	}
+	for i, col := range cols {
+		from := append([]string{}, constraints[i].From...)
+		to := append([]string{}, constraints[i].To...)
+		for _, name := range names {
+			if !contains(from, name) {
+				from = append(from, name)
+			}
+			if !contains(to, name) {
+				to = append(to, name)
+			}
+		}
+		if len(from) == len(constraints[i].From) && len(to) == len(constraints[i].To) {
+			continue
+		}
+		err := b.graph.SetVertexConstraints(ctx, col.Name(), arango.VertexConstraints{From: from, To: to})
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to add node collections to %v edge definition", col.Name())
+		}
+	}
+	for _, name := range names {
+		if b.nodes[name] != nil {
+			continue
+		}
+		col, err := b.graph.VertexCollection(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to create %v vertex collection", name)
+		}
+		b.nodes[name] = col
+	}
+	return nil
+}
+
+// ReadNodes reads the nodes of the named collection ordered by _key
+func (b *arangoBackend) ReadNodes(ctx context.Context, collection string) ([]*Node, error) {
+	nodes, err := b.collectionOf(collection + "/")
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Node, 0)
+	err = b.readAll(ctx, nodes, func(cursor arango.Cursor) error {
+		var node Node
+		_, err := cursor.ReadDocument(ctx, &node)
+		res = append(res, &node)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListNodes reads the page of the nodes of the named collection selected by the
+// filter using a single query
+func (b *arangoBackend) ListNodes(ctx context.Context, collection string, filter *NodeFilter, page *Page) ([]*Node, error) {
+	nodes, err := b.collectionOf(collection + "/")
+	if err != nil {
+		return nil, err
+	}
+	query := "FOR d IN @@collection "
+	vars := map[string]interface{}{"@collection": nodes.Name()}
+	if filter.NamePrefix != "" {
+		query += "FILTER STARTS_WITH(NOT_NULL(d.name, d._key), @namePrefix) "
+		vars["namePrefix"] = filter.NamePrefix
+	}
+	if filter.MinWeight != 0 {
+		query += "FILTER d.weight >= @minWeight "
+		vars["minWeight"] = filter.MinWeight
+	}
+	for i, k := range filter.dataKeys() {
+		query += fmt.Sprintf("FILTER d.data[@dataKey%d] == @dataValue%d ", i, i)
+		vars[fmt.Sprintf("dataKey%d", i)] = k
+		vars[fmt.Sprintf("dataValue%d", i)] = filter.Data[k]
+	}
+	query += "SORT d._key "
+	if page.Offset > 0 || page.Limit > 0 {
+		limit := page.Limit
+		if limit <= 0 {
+			limit = math.MaxInt32
+		}
+		query += "LIMIT @offset, @limit "
+		vars["offset"], vars["limit"] = page.Offset, limit
+	}
+	cursor, err := b.db.Query(ctx, query+"RETURN d", vars)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to list collection: %v", nodes.Name())
+	}
+	defer cursor.Close()
+	res := make([]*Node, 0)
+	for cursor.HasMore() {
+		var node Node
+		_, err := cursor.ReadDocument(ctx, &node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to list collection: %v", nodes.Name())
+		}
+		res = append(res, &node)
+	}
+	return res, nil
+}
+
+// UpsertLink creates the link or executes the designated operation on the
+// existing link using a single atomic UPSERT
+func (b *arangoBackend) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	var update string
+	switch op {
+	case IncrementLinkOp:
+		update = "MERGE(@link, {weight: OLD.weight + 1})"
+	default:
+		update = "(@link.weight < 0 OR OLD.weight == @link.weight OR OLD.data == @link.data) ? {} : @link"
+	}
+	var upserted Link
+	err = b.upsert(
+		ctx,
+		"UPSERT {_key: @link._key} INSERT @link UPDATE "+update+" IN @@collection RETURN NEW",
+		map[string]interface{}{"@collection": links.Name(), "link": link},
+		&upserted,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to upsert link: %v", link)
+	}
+	return &upserted, nil
+}
+
+// UpsertLinks creates the links or executes the designated operation on the
+// existing links using multi-document operations per links collection
+func (b *arangoBackend) UpsertLinks(ctx context.Context, types []SemanticType, links []*Link, op LinkOp) ([]*Link, error) {
+	stored := make([]*Link, len(links))
+	errs := make(BatchError, len(links))
+	batches := make(map[SemanticType][]int)
+	for i, typ := range types {
+		batches[typ.abs()] = append(batches[typ.abs()], i)
+	}
+	for typ, batch := range batches {
+		col, err := b.linksOf(typ)
+		if err != nil {
+			for _, i := range batch {
+				errs[i] = err
+			}
+			continue
+		}
+		keys := make([]string, 0, len(batch))
+		for _, i := range batch {
+			keys = append(keys, links[i].Key)
+		}
+		upsertDocuments(ctx, col, keys, errs.slice(batch), func(j int, doc []byte) ([]byte, error) {
+			doc, link, err := upsertLinkDocument(doc, links[batch[j]], op)
+			stored[batch[j]] = link
+			return doc, err
+		})
+	}
+	for i, err := range errs {
+		if err != nil {
+			stored[i] = nil
+		}
+	}
+	return stored, errs.orNil()
+}
+
+// ReadLink reads the link with the designated key
+func (b *arangoBackend) ReadLink(ctx context.Context, typ SemanticType, key string) (*Link, error) {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	var link Link
+	_, err = links.ReadDocument(ctx, key, &link)
+	if arango.IsNotFound(err) {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no link for key: %v", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// RemoveLink removes the link with the designated key if it exists
+func (b *arangoBackend) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return err
+	}
+	_, err = links.RemoveDocument(ctx, key)
+	if !arango.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ReadLinks reads the links of the collection of the designated SemanticType ordered by _key
+func (b *arangoBackend) ReadLinks(ctx context.Context, typ SemanticType) ([]*Link, error) {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Link, 0)
+	err = b.readAll(ctx, links, func(cursor arango.Cursor) error {
+		var link Link
+		_, err := cursor.ReadDocument(ctx, &link)
+		res = append(res, &link)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReadAssociations reads all associations from the Associations collection
+func (b *arangoBackend) ReadAssociations(ctx context.Context) (map[string]*Association, error) {
+	cursor, err := b.db.Query(ctx, "FOR a IN Associations RETURN a", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to read associations")
+	}
+	defer cursor.Close()
+	associations := make(map[string]*Association)
+	for cursor.HasMore() {
+		var a Association
+		_, err := cursor.ReadDocument(ctx, &a)
+		if err != nil {
+			return nil, errors.Wrap(err, "sst: failed to read associations")
+		}
+		associations[a.Key] = &a
+	}
+	return associations, nil
+}
+
+// InsertAssociation stores the association in the Associations collection unless
+// an association with the same key is already stored
+func (b *arangoBackend) InsertAssociation(ctx context.Context, a *Association) (*Association, error) {
+	_, err := b.associations.CreateDocument(ctx, a)
+	if err == nil {
+		return a, nil
+	}
+	if !arango.IsConflict(err) {
+		return nil, errors.Wrapf(err, "sst: failed to create association: %v", a)
+	}
+	var existing Association
+	_, err = b.associations.ReadDocument(ctx, a.Key, &existing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read association: %v", a.Key)
+	}
+	return &existing, nil
+}
+
+// ReadTimeline reads the head of the named timeline from the Timelines collection
+func (b *arangoBackend) ReadTimeline(ctx context.Context, name string) ([]string, error) {
+	var timeline timelineDocument
+	_, err := b.timelines.ReadDocument(ctx, name, &timeline)
+	if arango.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read timeline: %v", name)
+	}
+	return timeline.Head, nil
+}
+
+// WriteTimeline stores the head of the named timeline in the Timelines collection
+func (b *arangoBackend) WriteTimeline(ctx context.Context, name string, head []string) error {
+	cursor, err := b.db.Query(
+		ctx,
+		"UPSERT {_key: @key} INSERT @timeline REPLACE @timeline IN Timelines",
+		map[string]interface{}{"key": name, "timeline": &timelineDocument{Key: name, Head: head}},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to write timeline: %v", name)
+	}
+	return cursor.Close()
+}
+
+// AppendEvents records the observation of events in the Events collection
+func (b *arangoBackend) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	docs := make([]*eventDocument, 0, len(events))
+	for _, e := range events {
+		docs = append(docs, &eventDocument{Node: e.ID, Time: formatEventTime(e.Time), Timeline: timeline})
+	}
+	_, errs, err := b.events.CreateDocuments(ctx, docs)
+	if err == nil {
+		err = errs.FirstNonNil()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to record events of timeline: %v", timeline)
+	}
+	return nil
+}
+
+// ReadEvents reads events observed within the designated time range from the Events collection
+func (b *arangoBackend) ReadEvents(ctx context.Context, timeline string, from, to time.Time) ([]*TimelineEvent, error) {
+	return b.queryEvents(
+		ctx,
+		timeline,
+		"FOR e IN Events FILTER e.timeline == @timeline AND e.time >= @from AND e.time < @to SORT e.time, e.node RETURN e",
+		map[string]interface{}{"timeline": timeline, "from": formatEventTime(from), "to": formatEventTime(to)},
+	)
+}
+
+// ReadActiveEvents reads the latest events observed at or before the designated time from the Events collection
+func (b *arangoBackend) ReadActiveEvents(ctx context.Context, timeline string, at time.Time) ([]*TimelineEvent, error) {
+	return b.queryEvents(
+		ctx,
+		timeline,
+		"LET latest = FIRST(FOR e IN Events FILTER e.timeline == @timeline AND e.time <= @at SORT e.time DESC LIMIT 1 RETURN e.time) "+
+			"FOR e IN Events FILTER e.timeline == @timeline AND e.time == latest SORT e.node RETURN e",
+		map[string]interface{}{"timeline": timeline, "at": formatEventTime(at)},
+	)
+}
+
+// queryEvents reads events of the timeline returned by the designated query
+func (b *arangoBackend) queryEvents(ctx context.Context, timeline, query string, vars map[string]interface{}) ([]*TimelineEvent, error) {
+	cursor, err := b.db.Query(ctx, query, vars)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read events of timeline: %v", timeline)
+	}
+	defer cursor.Close()
+	events := make([]*TimelineEvent, 0)
+	for cursor.HasMore() {
+		var doc eventDocument
+		_, err := cursor.ReadDocument(ctx, &doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to read events of timeline: %v", timeline)
+		}
+		e, err := doc.event()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// Begin starts an ArangoDB stream transaction writing to the collections of the SST
+func (b *arangoBackend) Begin(ctx context.Context) (BackendTx, error) {
+	collections := []string{b.events.Name(), b.timelines.Name()}
+	b.mu.RLock()
+	for _, col := range b.nodes {
+		collections = append(collections, col.Name())
+	}
+	b.mu.RUnlock()
+	for _, typ := range linkTypes(nil) {
+		links, err := b.linksOf(typ)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, links.Name())
+	}
+	tid, err := b.db.BeginTransaction(ctx, arango.TransactionCollections{Write: collections}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to begin transaction")
+	}
+	return &arangoTx{b: b, tid: tid}, nil
+}
+
+// Traverse walks the graph breadth-first using an AQL graph traversal, or reads
+// the links of the start node directly when walking a single step. Each link is
+// walked once per path, so that parallel links and links to nodes already
+// reached are returned as walk does, and negated links end the path unless
+// they are included.
+func (b *arangoBackend) Traverse(ctx context.Context, startID string, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	opts = traversalOptions(opts)
+	if opts.Depth < 1 {
+		return nil, nil, nil
+	}
+	for _, typ := range opts.SemanticTypes {
+		if _, err := b.linksOf(typ); err != nil {
+			return nil, nil, err
+		}
+	}
+	dirs := walkedDirections(opts)
+	vars := map[string]interface{}{
+		"negated": opts.IncludeNegated,
+		"start":   startID,
+	}
+	var query string
+	if opts.Depth == 1 {
+		query = adjacentLinksQuery(dirs)
+	} else {
+		query = traversalQuery(dirs)
+		vars["depth"] = opts.Depth
+	}
+	cursor, err := b.db.Query(ctx, query, vars)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "sst: failed to traverse from: %v", startID)
+	}
+	defer cursor.Close()
+
+	nodes := make([]*Node, 0)
+	links := make([]*Link, 0)
+	seenNodes := map[string]bool{startID: true}
+	seenLinks := make(map[string]bool)
+	for cursor.HasMore() {
+		var step struct {
+			Node struct {
+				Node
+				arangoDocument
+			} `json:"node"`
+			Link struct {
+				Link
+				arangoDocument
+			} `json:"link"`
+		}
+		_, err := cursor.ReadDocument(ctx, &step)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "sst: failed to read traversal from: %v", startID)
+		}
+		if !seenLinks[step.Link.ID] {
+			seenLinks[step.Link.ID] = true
+			link := step.Link.Link
+			links = append(links, &link)
+		}
+		if step.Node.ID != "" && !seenNodes[step.Node.ID] {
+			seenNodes[step.Node.ID] = true
+			node := step.Node.Node
+			node.Prefix, _ = splitNodeID(step.Node.ID)
+			node.Prefix += "/"
+			nodes = append(nodes, &node)
+		}
+	}
+	return nodes, links, nil
+}
+
+// traversalQuery returns the AQL graph traversal walking the links collections
+// in their designated directions
+func traversalQuery(dirs map[SemanticType]Direction) string {
+	names := make([]string, 0, len(dirs))
+	for _, typ := range linkTypes(nil) {
+		if dir, walked := dirs[typ]; walked {
+			names = append(names, strings.ToUpper(dir.String())+" "+typ.String())
+		}
+	}
+	return fmt.Sprintf(
+		"FOR v, e IN 1..@depth ANY @start %v "+
+			"PRUNE !@negated AND LEFT(e._key, 1) == \"-\" "+
+			"OPTIONS {bfs: true, uniqueEdges: \"path\"} "+
+			"FILTER @negated OR LEFT(e._key, 1) != \"-\" "+
+			"RETURN {node: v, link: e}",
+		strings.Join(names, ", "),
+	)
+}
+
+// adjacentLinksQuery returns the AQL query reading the links of the start node,
+// and the nodes they link, from the links collections in their designated
+// directions
+func adjacentLinksQuery(dirs map[SemanticType]Direction) string {
+	subqueries := []string{"[]"}
+	for _, typ := range linkTypes(nil) {
+		dir, walked := dirs[typ]
+		if !walked {
+			continue
+		}
+		incident := "e._from == @start OR e._to == @start"
+		switch dir {
+		case Outbound:
+			incident = "e._from == @start"
+		case Inbound:
+			incident = "e._to == @start"
+		}
+		subqueries = append(subqueries, fmt.Sprintf(
+			"(FOR e IN %v FILTER %v FILTER @negated OR LEFT(e._key, 1) != \"-\" "+
+				"RETURN {node: DOCUMENT(e._from == @start ? e._to : e._from), link: e})",
+			typ.String(), incident,
+		))
+	}
+	return fmt.Sprintf("FOR step IN UNION(%v) RETURN step", strings.Join(subqueries, ", "))
+}
+
+// Query executes the designated AQL query
+func (b *arangoBackend) Query(ctx context.Context, query string, vars map[string]interface{}) (arango.Cursor, error) {
+	return b.db.Query(ctx, query, vars)
+}
+
+// readAll reads the documents of the collection ordered by _key, calling read for each document
+func (b *arangoBackend) readAll(ctx context.Context, col arango.Collection, read func(cursor arango.Cursor) error) error {
+	cursor, err := b.db.Query(ctx, "FOR d IN @@collection SORT d._key RETURN d", map[string]interface{}{
+		"@collection": col.Name(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to read collection: %v", col.Name())
+	}
+	defer cursor.Close()
+	for cursor.HasMore() {
+		err := read(cursor)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to read collection: %v", col.Name())
+		}
+	}
+	return nil
+}
+
+// documentCollection opens the designated document collection, creating it if it does not exist
+func (b *arangoBackend) documentCollection(ctx context.Context, name string) (arango.Collection, error) {
+	exists, err := b.db.CollectionExists(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var col arango.Collection
+	if exists {
+		col, err = b.db.Collection(ctx, name)
+	} else {
+		col, err = b.db.CreateCollection(ctx, name, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to create %v collection", name)
+	}
+	return col, nil
+}
+
+// collectionOf identifies node collection based on node prefix
+func (b *arangoBackend) collectionOf(prefix string) (arango.Collection, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var col arango.Collection
+	if len(prefix) > 0 {
+		col = b.nodes[prefix[:len(prefix)-1]]
+	}
+	if col == nil {
+		return nil, errors.New(fmt.Sprintf("sst: no node collection for prefix: %v", prefix))
+	}
+	return col, nil
+}
+
+// ApplySchema sets the JSON Schema rules of the node and link collections
+// constrained by the schema, validating new and modified documents. The rules
+// of other collections are left unchanged.
+func (b *arangoBackend) ApplySchema(ctx context.Context, schema *Schema, associations []*Association) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for kind, col := range b.nodes {
+		err := setArangoSchema(ctx, col, schema.nodeJSONSchema(kind))
+		if err != nil {
+			return err
+		}
+	}
+	for _, typ := range linkTypes(nil) {
+		col, err := b.linksOf(typ)
+		if err != nil {
+			return err
+		}
+		err = setArangoSchema(ctx, col, schema.linkJSONSchema(associations, typ))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setArangoSchema sets the JSON Schema rule of the collection, unless nil
+func setArangoSchema(ctx context.Context, col arango.Collection, rule map[string]interface{}) error {
+	if rule == nil {
+		return nil
+	}
+	err := col.SetProperties(ctx, arango.SetCollectionPropertiesOptions{
+		Schema: &arango.CollectionSchemaOptions{
+			Rule:    rule,
+			Level:   arango.CollectionSchemaLevelModerate,
+			Message: "sst: document violates the schema of collection " + col.Name(),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to set schema of collection: %v", col.Name())
+	}
+	return nil
+}
+
+// linksOf identifies links collection based on SemanticType needed
+func (b *arangoBackend) linksOf(typ SemanticType) (arango.Collection, error) {
+	switch typ.abs() {
+	case Near:
+		return b.near, nil
+	case Follows:
+		return b.follows, nil
+	case Contains:
+		return b.contains, nil
+	case Expresses:
+		return b.expresses, nil
+	}
+	return nil, errors.New(fmt.Sprintf("sst: no link collection for semantic type: %v", int(typ)))
+}
+
+// arangoTx executes writes within an ArangoDB stream transaction
+type arangoTx struct {
+	b   *arangoBackend
+	tid arango.TransactionID
+}
+
+// UpsertNode upserts the node within the stream transaction
+func (t *arangoTx) UpsertNode(ctx context.Context, node *Node) error {
+	return t.b.UpsertNode(arango.WithTransactionID(ctx, t.tid), node)
+}
+
+// RemoveNode removes the node within the stream transaction
+func (t *arangoTx) RemoveNode(ctx context.Context, id string) error {
+	return t.b.RemoveNode(arango.WithTransactionID(ctx, t.tid), id)
+}
+
+// UpsertLink upserts the link within the stream transaction
+func (t *arangoTx) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	return t.b.UpsertLink(arango.WithTransactionID(ctx, t.tid), typ, link, op)
+}
+
+// RemoveLink removes the link within the stream transaction
+func (t *arangoTx) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	return t.b.RemoveLink(arango.WithTransactionID(ctx, t.tid), typ, key)
+}
+
+// WriteTimeline stores the head of the named timeline within the stream transaction
+func (t *arangoTx) WriteTimeline(ctx context.Context, name string, head []string) error {
+	return t.b.WriteTimeline(arango.WithTransactionID(ctx, t.tid), name, head)
+}
+
+// AppendEvents records the observation of events within the stream transaction
+func (t *arangoTx) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	return t.b.AppendEvents(arango.WithTransactionID(ctx, t.tid), timeline, events)
+}
+
+// Commit commits the stream transaction
+func (t *arangoTx) Commit(ctx context.Context) error {
+	err := t.b.db.CommitTransaction(ctx, t.tid, nil)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to commit transaction")
+	}
+	return nil
+}
+
+// Abort aborts the stream transaction
+func (t *arangoTx) Abort(ctx context.Context) error {
+	err := t.b.db.AbortTransaction(ctx, t.tid, nil)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to abort transaction")
+	}
+	return nil
+}
+
+// upsert runs the UPSERT query reading the upserted document into result.
+// Concurrent UPSERTs of the same document conflict, in which case the query is
+// retried so that it executes against the document written by the winner.
+func (b *arangoBackend) upsert(ctx context.Context, query string, vars map[string]interface{}, result interface{}) error {
+	var err error
+	for attempt := 0; attempt < maxUpsertAttempts; attempt++ {
+		var cursor arango.Cursor
+		cursor, err = b.db.Query(ctx, query, vars)
+		if arango.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		defer cursor.Close()
+		if result != nil {
+			_, err = cursor.ReadDocument(ctx, result)
+		}
+		return err
+	}
+	return err
+}
+
+// upsertDocuments reads the documents with the designated keys, determines the
+// documents to store by applying upsert to each key in order, and creates or
+// updates them, with one multi-document round-trip per operation: up to three
+// round-trips to read, create and update the documents. Writes are conditional
+// on the documents being unchanged since read, documents changed concurrently
+// are read and upserted again in further round-trips. Errors are reported in
+// errs by index of key.
+func upsertDocuments(ctx context.Context, col arango.Collection, keys []string, errs []*error, upsert func(i int, stored []byte) ([]byte, error)) {
+	pending := make([]int, 0, len(keys))
+	for i := range keys {
+		pending = append(pending, i)
+	}
+	for attempt := 0; attempt < maxUpsertAttempts && len(pending) > 0; attempt++ {
+		conflicted := upsertDocumentsOnce(ctx, col, keys, pending, errs, upsert)
+		pending = pending[:0]
+		for i, key := range keys {
+			if conflicted[key] && attempt+1 < maxUpsertAttempts {
+				*errs[i] = nil
+				pending = append(pending, i)
+			}
+		}
+	}
+}
+
+// upsertDocumentsOnce upserts the pending documents, returning the keys of documents changed concurrently
+func upsertDocumentsOnce(ctx context.Context, col arango.Collection, keys []string, pending []int, errs []*error, upsert func(i int, stored []byte) ([]byte, error)) map[string]bool {
+	unique := make([]string, 0, len(pending))
+	indexes := make(map[string][]int)
+	for _, i := range pending {
+		key := keys[i]
+		if _, ok := indexes[key]; !ok {
+			unique = append(unique, key)
+		}
+		indexes[key] = append(indexes[key], i)
+	}
+	conflicted := make(map[string]bool)
+	fail := func(key string, err error) {
+		if arango.IsConflict(err) || arango.IsPreconditionFailed(err) {
+			conflicted[key] = true
+		}
+		for _, i := range indexes[key] {
+			if *errs[i] == nil {
+				*errs[i] = err
+			}
+		}
+	}
+
+	existing := make([]map[string]interface{}, len(unique))
+	_, readErrs, err := col.ReadDocuments(ctx, unique, existing)
+	if err != nil {
+		for _, key := range unique {
+			fail(key, errors.Wrapf(err, "sst: failed to read documents from: %v", col.Name()))
+		}
+		return conflicted
+	}
+	docs := make(map[string][]byte, len(unique))
+	revs := make(map[string]string, len(unique))
+	for k, key := range unique {
+		if arango.IsNotFound(readErrs[k]) {
+			continue
+		}
+		if readErrs[k] != nil {
+			fail(key, errors.Wrapf(readErrs[k], "sst: failed to read document: %v", key))
+			continue
+		}
+		revs[key], _ = existing[k]["_rev"].(string)
+		delete(existing[k], "_id")
+		delete(existing[k], "_rev")
+		docs[key], err = json.Marshal(existing[k])
+		if err != nil {
+			fail(key, errors.Wrapf(err, "sst: failed to read document: %v", key))
+		}
+	}
+
+	changed := make(map[string]bool, len(unique))
+	for _, i := range pending {
+		key := keys[i]
+		if *errs[i] != nil {
+			continue
+		}
+		doc, err := upsert(i, docs[key])
+		if err != nil {
+			*errs[i] = err
+			continue
+		}
+		if doc != nil {
+			docs[key] = doc
+			changed[key] = true
+		}
+	}
+
+	created, createdKeys := make([]json.RawMessage, 0), make([]string, 0)
+	updated, updatedKeys, updatedRevs := make([]json.RawMessage, 0), make([]string, 0), make([]string, 0)
+	for _, key := range unique {
+		if !changed[key] {
+			continue
+		}
+		if rev, ok := revs[key]; ok {
+			updated, updatedKeys, updatedRevs = append(updated, docs[key]), append(updatedKeys, key), append(updatedRevs, rev)
+		} else {
+			created, createdKeys = append(created, docs[key]), append(createdKeys, key)
+		}
+	}
+	if len(created) > 0 {
+		_, writeErrs, err := col.CreateDocuments(ctx, created)
+		for k, key := range createdKeys {
+			failed := err
+			if failed == nil {
+				failed = writeErrs[k]
+			}
+			if failed != nil {
+				fail(key, errors.Wrapf(failed, "sst: failed to create document: %v", key))
+			}
+		}
+	}
+	if len(updated) > 0 {
+		_, writeErrs, err := col.UpdateDocuments(arango.WithRevisions(ctx, updatedRevs), updatedKeys, updated)
+		for k, key := range updatedKeys {
+			failed := err
+			if failed == nil {
+				failed = writeErrs[k]
+			}
+			if failed != nil {
+				fail(key, errors.Wrapf(failed, "sst: failed to update document: %v", key))
+			}
+		}
+	}
+	return conflicted
+}
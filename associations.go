@@ -1,11 +1,16 @@
 package sst
 
 import (
-	"fmt"
+	"context"
+	"sort"
 
 	"github.com/pkg/errors"
 )
 
+var (
+	associationConflict = errors.New("sst: conflicting association")
+)
+
 type SemanticType int
 
 const (
@@ -35,6 +40,14 @@ func (a SemanticType) String() string {
 	return "unknown"
 }
 
+// abs returns the positive SemanticType designating the link collection
+func (a SemanticType) abs() SemanticType {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
 // Association stores invariant relationship data as lookup tables to
 // reduce database storage.
 type Association struct {
@@ -74,18 +87,38 @@ var (
 	}
 )
 
-// CreateAssociation creates a new association
+// IsAssociationConflict returns true if the error designates an association
+// conflicting with an existing association with the same key, false otherwise.
+func IsAssociationConflict(err error) bool {
+	return errors.Cause(err) == associationConflict
+}
+
+// CreateAssociation creates a new association and stores it in the Backend
 func (s *SST) CreateAssociation(a *Association) error {
-	a.Key = ToDocumentKey(a.Key)
+	return s.CreateAssociationContext(context.Background(), a)
+}
+
+// CreateAssociationContext creates a new association and stores it in the Backend using the provided context
+func (s *SST) CreateAssociationContext(ctx context.Context, a *Association) error {
+	a.Key = identifierKey(a.Key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	existing := s.associations[a.Key]
 	if existing == nil {
+		stored, err := s.backend.InsertAssociation(ctx, a)
+		if err != nil {
+			return err
+		}
+		if *stored != *a {
+			return errors.Wrapf(associationConflict, "sst: failed to create association %v due to stored association %v", a, stored)
+		}
 		s.associations[a.Key] = a
 		return nil
 	}
-	if existing == a {
+	if *existing == *a {
 		return nil
 	}
-	return errors.New(fmt.Sprintf("sst: failed to create association %v due to existing association %v", a, existing))
+	return errors.Wrapf(associationConflict, "sst: failed to create association %v due to existing association %v", a, existing)
 }
 
 // MustCreateAssociation creates a new association, panics on error
@@ -95,3 +128,66 @@ func (s *SST) MustCreateAssociation(a *Association) {
 		panic(err)
 	}
 }
+
+// Associations returns the associations of this SST ordered by key
+func (s *SST) Associations() []*Association {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	associations := make([]*Association, 0, len(s.associations))
+	for _, a := range s.associations {
+		copied := *a
+		associations = append(associations, &copied)
+	}
+	sort.Slice(associations, func(i, j int) bool {
+		return associations[i].Key < associations[j].Key
+	})
+	return associations
+}
+
+// association returns the association with the designated key, nil if there is none
+func (s *SST) association(key string) *Association {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.associations[key]
+}
+
+// loadAssociations merges the configured associations with associations stored
+// in the Backend and stores configured associations that are not yet stored.
+func (s *SST) loadAssociations(ctx context.Context, configured map[string]*Association) error {
+	stored, err := s.backend.ReadAssociations(ctx)
+	if err != nil {
+		return err
+	}
+	s.associations = make(map[string]*Association)
+	for k, a := range stored {
+		s.associations[k] = a
+	}
+	for k, a := range configured {
+		existing := stored[k]
+		if existing == nil {
+			existing, err = s.backend.InsertAssociation(ctx, a)
+			if err != nil {
+				return err
+			}
+		}
+		if *existing != *a {
+			return errors.Wrapf(associationConflict, "sst: configured association %v differs from stored association %v", a, existing)
+		}
+		s.associations[k] = a
+	}
+	return nil
+}
+
+// Phrase returns the phrase describing a link of this association walked forward,
+// from Link.From to Link.To, or backward, taking link negation into account.
+func (a *Association) Phrase(forward, negated bool) string {
+	switch {
+	case forward && !negated:
+		return a.Fwd
+	case !forward && !negated:
+		return a.Bwd
+	case forward && negated:
+		return a.Nfwd
+	}
+	return a.Nbwd
+}
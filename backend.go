@@ -0,0 +1,233 @@
+package sst
+
+import (
+	"context"
+	"time"
+
+	"github.com/arangodb/go-driver"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound is returned by a Backend when the designated document does not exist
+	ErrNotFound = errors.New("sst: document not found")
+)
+
+// Backend stores the nodes and links of a Semantic Spacetime graph.
+//
+// Links are stored per SemanticType, mirroring the Near, Follows, Contains and
+// Expresses link collections. A negative SemanticType designates the same link
+// collection as its positive counterpart.
+type Backend interface {
+	// Open prepares the backend for the designated configuration.
+	Open(ctx context.Context, config *Config) error
+	// Close releases resources held by the backend.
+	Close() error
+
+	// UpsertNode idempotently inserts the node into the node collection
+	// specified by node.Prefix. An existing node is only updated if the node
+	// carries data or weight that differs from the stored one.
+	UpsertNode(ctx context.Context, node *Node) error
+	// UpsertNodes idempotently inserts the nodes with a read, a create and an
+	// update round-trip per node collection, plus retries on conflicts with
+	// concurrent changes. Returns a BatchError reporting the nodes that failed.
+	UpsertNodes(ctx context.Context, nodes []*Node) error
+	// ReadNode reads the node with the designated _id.
+	ReadNode(ctx context.Context, id string) (*Node, error)
+	// RemoveNode removes the node with the designated _id if it exists. Links
+	// of the node are not removed.
+	RemoveNode(ctx context.Context, id string) error
+	// ReadNodeCollections reads the names of the stored node collections.
+	ReadNodeCollections(ctx context.Context) ([]string, error)
+	// AddNodeCollection creates the named node collection if it does not
+	// exist, allowing links from and to its nodes.
+	AddNodeCollection(ctx context.Context, name string) error
+	// RemoveNodeCollection removes the named node collection and its nodes if
+	// it exists. Links of its nodes are not removed.
+	RemoveNodeCollection(ctx context.Context, name string) error
+	// ReadNodes reads the nodes of the named node collection ordered by _key.
+	ReadNodes(ctx context.Context, collection string) ([]*Node, error)
+	// ListNodes reads the page of the nodes of the named node collection
+	// selected by the filter, ordered by _key.
+	ListNodes(ctx context.Context, collection string, filter *NodeFilter, page *Page) ([]*Node, error)
+
+	// UpsertLink creates the link in the link collection of the designated
+	// SemanticType or executes op on the existing link.
+	UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error)
+	// UpsertLinks creates the links or executes the designated operation on the
+	// existing links with a read, a create and an update round-trip per links
+	// collection, plus retries on conflicts with concurrent changes. Each link
+	// is of the SemanticType at the same index. Returns the stored links and a
+	// BatchError reporting the links that failed.
+	UpsertLinks(ctx context.Context, types []SemanticType, links []*Link, op LinkOp) ([]*Link, error)
+	// ReadLink reads the link with the designated key.
+	ReadLink(ctx context.Context, typ SemanticType, key string) (*Link, error)
+	// RemoveLink removes the link with the designated key if it exists.
+	RemoveLink(ctx context.Context, typ SemanticType, key string) error
+	// ReadLinks reads the links of the link collection of the designated
+	// SemanticType ordered by _key.
+	ReadLinks(ctx context.Context, typ SemanticType) ([]*Link, error)
+
+	// ReadAssociations reads all stored associations.
+	ReadAssociations(ctx context.Context) (map[string]*Association, error)
+	// InsertAssociation stores the association if no association with the same
+	// key is stored. Returns the stored association.
+	InsertAssociation(ctx context.Context, a *Association) (*Association, error)
+
+	// ApplySchema pushes the schema of the nodes and of the links of the
+	// associations to the collection-level schema validation of the backend.
+	// Backends without schema validation do nothing.
+	ApplySchema(ctx context.Context, schema *Schema, associations []*Association) error
+
+	// ReadTimeline reads the _ids of the head events of the named timeline.
+	// Returns nil if the timeline is not stored.
+	ReadTimeline(ctx context.Context, name string) ([]string, error)
+	// WriteTimeline stores the _ids of the head events of the named timeline.
+	WriteTimeline(ctx context.Context, name string, head []string) error
+
+	// AppendEvents records the observation of events on the named timeline.
+	AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error
+	// ReadEvents reads the events observed on the named timeline from the from
+	// time, inclusive, to the to time, exclusive, ordered by time and _id.
+	ReadEvents(ctx context.Context, timeline string, from, to time.Time) ([]*TimelineEvent, error)
+	// ReadActiveEvents reads the latest events observed on the named timeline at
+	// or before the designated time, ordered by _id.
+	ReadActiveEvents(ctx context.Context, timeline string, at time.Time) ([]*TimelineEvent, error)
+
+	// Begin starts a transaction. Writes executed through the transaction are
+	// committed all together or not at all.
+	Begin(ctx context.Context) (BackendTx, error)
+
+	// Traverse walks links designated by opts, the defaults if nil, from the
+	// node with the startID _id. Reached nodes, other than the start node, and traversed links are returned
+	// in breadth-first order without duplicates.
+	Traverse(ctx context.Context, startID string, opts *TraversalOptions) ([]*Node, []*Link, error)
+
+	// Query executes the designated backend specific query
+	Query(ctx context.Context, query string, vars map[string]interface{}) (driver.Cursor, error)
+}
+
+// BackendTx executes writes to a Backend within a transaction. A BackendTx is
+// not safe for concurrent use and must be committed or aborted.
+type BackendTx interface {
+	// UpsertNode executes Backend.UpsertNode within the transaction.
+	UpsertNode(ctx context.Context, node *Node) error
+	// RemoveNode executes Backend.RemoveNode within the transaction.
+	RemoveNode(ctx context.Context, id string) error
+	// UpsertLink executes Backend.UpsertLink within the transaction.
+	UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error)
+	// RemoveLink executes Backend.RemoveLink within the transaction.
+	RemoveLink(ctx context.Context, typ SemanticType, key string) error
+	// WriteTimeline executes Backend.WriteTimeline within the transaction.
+	WriteTimeline(ctx context.Context, name string, head []string) error
+	// AppendEvents executes Backend.AppendEvents within the transaction.
+	AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error
+
+	// Commit commits the writes of the transaction.
+	Commit(ctx context.Context) error
+	// Abort rolls back the writes of the transaction.
+	Abort(ctx context.Context) error
+}
+
+// TimelineEvent records the observation of an event on a timeline
+type TimelineEvent struct {
+	// ID is the _id of the event node
+	ID string
+	// Time is the observation time of the event
+	Time time.Time
+}
+
+// IsNotFound returns true if the error designates a missing document, false otherwise.
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrNotFound
+}
+
+// linkTypes returns the distinct link collection SemanticTypes for the designated
+// types, or all of them if none are designated.
+func linkTypes(types []SemanticType) []SemanticType {
+	if len(types) == 0 {
+		return []SemanticType{Near, Follows, Contains, Expresses}
+	}
+	seen := make(map[SemanticType]bool)
+	distinct := make([]SemanticType, 0, len(types))
+	for _, typ := range types {
+		typ = typ.abs()
+		if !seen[typ] {
+			seen[typ] = true
+			distinct = append(distinct, typ)
+		}
+	}
+	return distinct
+}
+
+// linkRef designates a stored link
+type linkRef struct {
+	typ SemanticType
+	key string
+}
+
+// linkIndex provides access to links indexed by the _id of their endpoints
+type linkIndex interface {
+	// adjacent returns the links from (Outbound) or to (Inbound) the node with the designated _id
+	adjacent(id string, dir Direction) ([]linkRef, error)
+	// readLinkRef reads the designated link
+	readLinkRef(ref linkRef) (*Link, error)
+	// readNode reads the node with the designated _id
+	readNode(id string) (*Node, error)
+}
+
+// walk traverses the link index breadth-first, see Backend.Traverse
+func walk(index linkIndex, startID string, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	dirs := walkedDirections(opts)
+
+	nodes := make([]*Node, 0)
+	links := make([]*Link, 0)
+	seenNodes := map[string]bool{startID: true}
+	seenLinks := make(map[linkRef]bool)
+	frontier := []string{startID}
+	for level := 0; level < opts.Depth && len(frontier) > 0; level++ {
+		next := make([]string, 0)
+		for _, id := range frontier {
+			for _, dir := range []Direction{Outbound, Inbound} {
+				refs, err := index.adjacent(id, dir)
+				if err != nil {
+					return nil, nil, err
+				}
+				for _, ref := range refs {
+					walkedDir, walked := dirs[ref.typ]
+					if !walked || (walkedDir != Any && walkedDir != dir) || seenLinks[ref] {
+						continue
+					}
+					if !opts.IncludeNegated && MustLinkKeyNegated(ref.key) {
+						continue
+					}
+					seenLinks[ref] = true
+					link, err := index.readLinkRef(ref)
+					if err != nil {
+						return nil, nil, err
+					}
+					links = append(links, link)
+					otherID := link.To
+					if dir == Inbound {
+						otherID = link.From
+					}
+					if seenNodes[otherID] {
+						continue
+					}
+					seenNodes[otherID] = true
+					node, err := index.readNode(otherID)
+					if IsNotFound(err) {
+						continue
+					}
+					if err != nil {
+						return nil, nil, err
+					}
+					nodes = append(nodes, node)
+					next = append(next, otherID)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nodes, links, nil
+}
@@ -0,0 +1,320 @@
+package sst
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BatchError reports the errors of the items of a batch operation. The error
+// of each item is at the index of the item, nil if the item succeeded.
+type BatchError []error
+
+// Error summarizes the failed items
+func (e BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("sst: %d of %d batch items failed, first error: %v", failed, len(e), first)
+}
+
+// orNil returns nil if no item failed
+func (e BatchError) orNil() error {
+	for _, err := range e {
+		if err != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// slice returns pointers to the errors of the designated items
+func (e BatchError) slice(indexes []int) []*error {
+	errs := make([]*error, 0, len(indexes))
+	for _, i := range indexes {
+		errs = append(errs, &e[i])
+	}
+	return errs
+}
+
+// CreateNodes idempotently creates the nodes with a read, a create and an
+// update round-trip per node collection, plus retries on conflicts with
+// concurrent changes. Node.Prefix designates the node collection, as in "Node/",
+// and Node.Key is the name of the node, stored as Node.Name and encoded using
+// ToDocumentKey. Returns the created nodes and a BatchError reporting the nodes
+// that failed.
+func (s *SST) CreateNodes(nodes []*Node) ([]*Node, error) {
+	return s.CreateNodesContext(context.Background(), nodes)
+}
+
+// CreateNodesContext invokes CreateNodes using the provided context
+func (s *SST) CreateNodesContext(ctx context.Context, nodes []*Node) ([]*Node, error) {
+	errs := make(BatchError, len(nodes))
+	created := make([]*Node, len(nodes))
+	valid := make([]*Node, 0, len(nodes))
+	indexes := make([]int, 0, len(nodes))
+	for i, node := range nodes {
+		n := *node
+		n.Key, n.Name = ToDocumentKey(n.Key), n.Key
+		err := s.validateNode(ctx, &n)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		created[i] = &n
+		valid = append(valid, &n)
+		indexes = append(indexes, i)
+	}
+	err := s.backend.UpsertNodes(ctx, valid)
+	if err != nil {
+		upsertErrs, ok := err.(BatchError)
+		if !ok {
+			return nil, err
+		}
+		for j, i := range indexes {
+			errs[i] = upsertErrs[j]
+		}
+	}
+	for i := range errs {
+		if errs[i] != nil {
+			created[i] = nil
+		}
+	}
+	return created, errs.orNil()
+}
+
+// MustCreateNodes invokes CreateNodes, but panics on error
+func (s *SST) MustCreateNodes(nodes []*Node) []*Node {
+	created, err := s.CreateNodes(nodes)
+	if err != nil {
+		panic(err)
+	}
+	return created
+}
+
+// CreateLinks idempotently creates the links with a read, a create and an
+// update round-trip per links collection, plus retries on conflicts with
+// concurrent changes. Link.From and Link.To designate the _ids of the linked
+// nodes and Link.SID the association, as with CreateLinkByID. Returns the stored
+// links and a BatchError reporting the links that failed.
+func (s *SST) CreateLinks(links []*Link) ([]*Link, error) {
+	return s.CreateLinksContext(context.Background(), links)
+}
+
+// CreateLinksContext invokes CreateLinks using the provided context
+func (s *SST) CreateLinksContext(ctx context.Context, links []*Link) ([]*Link, error) {
+	errs := make(BatchError, len(links))
+	types := make([]SemanticType, 0, len(links))
+	candidates := make([]*Link, 0, len(links))
+	indexes := make([]int, 0, len(links))
+	for i, l := range links {
+		association, link, err := s.newLink(l.From, l.SID, l.To, l.Data, l.Weight, false)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		link.Time = l.Time
+		types = append(types, association.SemanticType)
+		candidates = append(candidates, link)
+		indexes = append(indexes, i)
+	}
+	stored := make([]*Link, len(links))
+	upserted, err := s.backend.UpsertLinks(ctx, types, candidates, AddLinkOp)
+	if err != nil {
+		upsertErrs, ok := err.(BatchError)
+		if !ok {
+			return nil, err
+		}
+		for j, i := range indexes {
+			errs[i] = upsertErrs[j]
+		}
+	}
+	for j, i := range indexes {
+		if upserted != nil {
+			stored[i] = upserted[j]
+		}
+	}
+	return stored, errs.orNil()
+}
+
+// MustCreateLinks invokes CreateLinks, but panics on error
+func (s *SST) MustCreateLinks(links []*Link) []*Link {
+	stored, err := s.CreateLinks(links)
+	if err != nil {
+		panic(err)
+	}
+	return stored
+}
+
+// Batch groups writes that are committed all together or not at all. Writes
+// are executed in the order they are added to the batch once the batch is
+// committed, within a Backend transaction such as an ArangoDB stream transaction.
+// A Batch is not safe for concurrent use.
+type Batch struct {
+	// checks validate the writes before the transaction begins, as they may
+	// read the Backend
+	checks []func(ctx context.Context) error
+	heads  map[*Timeline][]*Node
+	ops    []func(ctx context.Context, tx BackendTx) error
+	s      *SST
+}
+
+// NewBatch creates an empty batch of writes to this SST
+func (s *SST) NewBatch() *Batch {
+	return &Batch{
+		heads: make(map[*Timeline][]*Node),
+		s:     s,
+	}
+}
+
+// CreateNode adds the idempotent creation of the node to the batch. Returns the node to be created.
+func (b *Batch) CreateNode(kind, key string, data map[string]interface{}, weight float64) *Node {
+	node := &Node{
+		Data:   data,
+		Key:    ToDocumentKey(key),
+		Name:   key,
+		Prefix: kind + "/",
+		Weight: weight,
+	}
+	b.checks = append(b.checks, func(ctx context.Context) error {
+		return b.s.validateNode(ctx, node)
+	})
+	b.ops = append(b.ops, func(ctx context.Context, tx BackendTx) error {
+		return tx.UpsertNode(ctx, node)
+	})
+	return node
+}
+
+// CreateLink adds CreateLink to the batch
+func (b *Batch) CreateLink(from *Node, rel string, to *Node, data map[string]interface{}, weight float64) error {
+	return b.linkOp(linkFrom(from), rel, linkTo(to), data, weight, false, AddLinkOp)
+}
+
+// CreateLinkByID adds CreateLinkByID to the batch
+func (b *Batch) CreateLinkByID(fromID, rel, toID string, data map[string]interface{}, weight float64) error {
+	return b.linkOp(fromID, rel, toID, data, weight, false, AddLinkOp)
+}
+
+// BlockLink adds BlockLink to the batch
+func (b *Batch) BlockLink(from *Node, rel string, to *Node, data map[string]interface{}, weight float64) error {
+	return b.linkOp(linkFrom(from), rel, linkTo(to), data, weight, true, AddLinkOp)
+}
+
+// BlockLinkByID adds BlockLinkByID to the batch
+func (b *Batch) BlockLinkByID(fromID, rel, toID string, data map[string]interface{}, weight float64) error {
+	return b.linkOp(fromID, rel, toID, data, weight, true, AddLinkOp)
+}
+
+// IncrementLink adds IncrementLink to the batch
+func (b *Batch) IncrementLink(from *Node, rel string, to *Node, data map[string]interface{}) error {
+	return b.linkOp(linkFrom(from), rel, linkTo(to), data, 0.0, false, IncrementLinkOp)
+}
+
+// DeleteLink adds DeleteLink to the batch
+func (b *Batch) DeleteLink(from *Node, rel string, to *Node, negate bool) error {
+	relKey := identifierKey(rel)
+	association := b.s.association(relKey)
+	if association == nil {
+		return errors.Wrapf(unknownAssociation, "sst: invalid link type: %v", relKey)
+	}
+	key := linkKey(linkFrom(from), association.Key, linkTo(to), negate)
+	b.ops = append(b.ops, func(ctx context.Context, tx BackendTx) error {
+		return tx.RemoveLink(ctx, association.SemanticType, key)
+	})
+	return nil
+}
+
+// NextEvent adds a singular next event on the timeline to the batch. Returns the event to be created.
+func (b *Batch) NextEvent(t *Timeline, kind, key string, data map[string]interface{}) *Node {
+	return b.NextEventsAt(t, time.Now(), []string{kind}, []string{key}, []map[string]interface{}{data})[0]
+}
+
+// NextEvents adds a set of next parallel events on the timeline to the batch. Returns the events to be created.
+func (b *Batch) NextEvents(t *Timeline, kind, keys []string, data []map[string]interface{}) []*Node {
+	return b.NextEventsAt(t, time.Now(), kind, keys, data)
+}
+
+// NextEventsAt adds a set of next parallel events observed at the designated
+// time on the timeline to the batch. Returns the events to be created.
+func (b *Batch) NextEventsAt(t *Timeline, at time.Time, kind, keys []string, data []map[string]interface{}) []*Node {
+	newset := newEvents(at, kind, keys, data)
+	b.heads[t] = nil
+	b.ops = append(b.ops, func(ctx context.Context, tx BackendTx) error {
+		err := t.record(ctx, tx, b.heads[t], newset)
+		if err != nil {
+			return err
+		}
+		b.heads[t] = newset
+		return nil
+	})
+	return newset
+}
+
+// Commit executes the writes of the batch. Either all writes are committed or,
+// if any write fails, none are.
+func (b *Batch) Commit() error {
+	return b.CommitContext(context.Background())
+}
+
+// CommitContext invokes Commit using the provided context
+func (b *Batch) CommitContext(ctx context.Context) error {
+	for _, check := range b.checks {
+		err := check(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	// Timelines are locked in a consistent order for the duration of the
+	// transaction so that batches and events recorded concurrently do not deadlock
+	timelines := make([]*Timeline, 0, len(b.heads))
+	for t := range b.heads {
+		timelines = append(timelines, t)
+	}
+	sort.Slice(timelines, func(i, j int) bool {
+		return timelines[i].key < timelines[j].key
+	})
+	for _, t := range timelines {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		b.heads[t] = t.head
+	}
+	err := b.s.update(ctx, func(tx BackendTx) error {
+		for _, op := range b.ops {
+			err := op(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range timelines {
+		t.head = b.heads[t]
+	}
+	return nil
+}
+
+// linkOp adds the creation of the link or the execution of op on the existing link to the batch
+func (b *Batch) linkOp(fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool, op LinkOp) error {
+	association, link, err := b.s.newLink(fromID, rel, toID, data, weight, negate)
+	if err != nil {
+		return err
+	}
+	b.ops = append(b.ops, func(ctx context.Context, tx BackendTx) error {
+		_, err := tx.UpsertLink(ctx, association.SemanticType, link, op)
+		return err
+	})
+	return nil
+}
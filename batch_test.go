@@ -0,0 +1,115 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBatch(t *testing.T, s *SST) {
+	nodes, err := s.CreateNodes([]*Node{
+		{Prefix: "Node/", Key: "New York", Weight: 1},
+		{Prefix: "Node/", Key: "Paris", Data: map[string]interface{}{"country": "France"}, Weight: 1},
+		{Prefix: "Missing/", Key: "London", Weight: 1},
+		{Prefix: "Node/", Key: "Paris", Weight: 2},
+	})
+	assert.Error(t, err)
+	errs := err.(BatchError)
+	assert.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[2])
+	assert.Nil(t, nodes[2])
+	assert.Equal(t, "New%20York", nodes[0].Key)
+	n, err := s.backend.ReadNode(context.TODO(), "Node/Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, n.Weight)
+	assert.Equal(t, map[string]interface{}{"country": "France"}, n.Data)
+
+	links, err := s.CreateLinks([]*Link{
+		{From: "Node/New%20York", SID: "related", To: "Node/Paris", Weight: 1},
+		{From: "Node/Paris", SID: "unknown", To: "Node/New%20York", Weight: 1},
+		{From: "Node/Paris", SID: "contains", To: "Node/New%20York", Weight: 1},
+	})
+	assert.Error(t, err)
+	errs = err.(BatchError)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Nil(t, links[1])
+	assert.Equal(t, linkKey("Node/New%20York", "related", "Node/Paris", false), links[0].Key)
+
+	links, err = s.CreateLinks([]*Link{
+		{From: "Node/New%20York", SID: "related", To: "Node/Paris", Weight: 1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, links[0].Weight)
+	neighbours, _, err := s.backend.Traverse(context.TODO(), "Node/Paris", &TraversalOptions{Direction: Any, Depth: 1})
+	assert.NoError(t, err)
+	assert.Len(t, neighbours, 1)
+}
+
+func TestBatch(t *testing.T) {
+	testBatch(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testBatch(t, s)
+}
+
+func testBatchCommit(t *testing.T, s *SST) {
+	journey := s.MustTimeline("journey")
+	b := s.NewBatch()
+	london := b.CreateNode("Node", "London", nil, 1)
+	paris := b.CreateNode("Node", "Paris", nil, 1)
+	assert.NoError(t, b.CreateLink(london, "related", paris, nil, 1))
+	assert.NoError(t, b.BlockLink(paris, "contains", london, nil, 1))
+	assert.Error(t, b.CreateLink(london, "unknown", paris, nil, 1))
+	e1 := b.NextEvent(journey, "Node", "Departure", nil)
+	e2 := b.NextEvent(journey, "Node", "Arrival", nil)
+	assert.Equal(t, []*Node{startEvent}, journey.PreviousEvents())
+	assert.NoError(t, b.Commit())
+
+	assert.Equal(t, []*Node{e2}, journey.PreviousEvents())
+	_, err := s.backend.ReadLink(context.TODO(), Near, linkKey("Node/London", "related", "Node/Paris", false))
+	assert.NoError(t, err)
+	_, err = s.backend.ReadLink(context.TODO(), Contains, linkKey("Node/Paris", "contains", "Node/London", true))
+	assert.NoError(t, err)
+	_, err = s.backend.ReadLink(context.TODO(), Follows, linkKey(MustNodeID(e1), "then", MustNodeID(e2), false))
+	assert.NoError(t, err)
+
+	b = s.NewBatch()
+	rome := b.CreateNode("Node", "Rome", nil, 1)
+	assert.NoError(t, b.CreateLink(paris, "related", rome, nil, 1))
+	assert.NoError(t, b.DeleteLink(london, "related", paris, false))
+	b.NextEvent(journey, "Node", "Transfer", nil)
+	b.CreateNode("Missing", "Berlin", nil, 1)
+	assert.Error(t, b.Commit())
+
+	assert.Equal(t, []*Node{e2}, journey.PreviousEvents())
+	_, err = s.backend.ReadNode(context.TODO(), "Node/Rome")
+	assert.True(t, IsNotFound(err))
+	_, err = s.backend.ReadLink(context.TODO(), Near, linkKey("Node/Paris", "related", "Node/Rome", false))
+	assert.True(t, IsNotFound(err))
+	_, err = s.backend.ReadLink(context.TODO(), Near, linkKey("Node/London", "related", "Node/Paris", false))
+	assert.NoError(t, err)
+	nodes, _, err := s.backend.Traverse(context.TODO(), "Node/Paris", &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	ids, err := s.backend.ReadTimeline(context.TODO(), journey.key)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Node/Arrival"}, ids)
+
+	_, err = journey.NextEvents([]string{"Node", "Missing"}, []string{"Lyon", "Nice"}, []map[string]interface{}{nil, nil})
+	assert.Error(t, err)
+	_, err = s.backend.ReadNode(context.TODO(), "Node/Lyon")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, []*Node{e2}, journey.PreviousEvents())
+}
+
+func TestBatchCommit(t *testing.T) {
+	testBatchCommit(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testBatchCommit(t, s)
+}
@@ -0,0 +1,726 @@
+package sst
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/arangodb/go-driver"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltAssociationsBucket = []byte("associations")
+	boltNodesBucket        = []byte("nodes")
+	boltLinksBucket        = []byte("links")
+	boltFromBucket         = []byte("from")
+	boltToBucket           = []byte("to")
+	boltTimelinesBucket    = []byte("timelines")
+	boltEventsBucket       = []byte("events")
+
+	// boltSeparator separates parts of index keys, it is not allowed in document keys
+	boltSeparator = []byte{0}
+
+	// boltLinkTypes maps link bucket names to their SemanticType
+	boltLinkTypes = map[string]SemanticType{
+		Near.String():      Near,
+		Follows.String():   Follows,
+		Contains.String():  Contains,
+		Expresses.String(): Expresses,
+	}
+)
+
+// boltBackend stores Semantic Spacetime in a single bbolt file.
+//
+// Associations and timelines are stored in the associations and timelines buckets.
+// Nodes are stored in a bucket per node collection within the nodes bucket and
+// links in a bucket per SemanticType within the links bucket, mirroring the
+// Near, Follows, Contains and Expresses collections. The from and to buckets
+// index links by the _id of their endpoints. Events of a timeline are indexed
+// by observation time in a bucket per timeline within the events bucket.
+type boltBackend struct {
+	db   *bolt.DB
+	path string
+}
+
+// boltIndex is a linkIndex within a bbolt transaction
+type boltIndex struct {
+	tx *bolt.Tx
+}
+
+// NewBoltBackend creates a Backend storing Semantic Spacetime in the bbolt file
+// at the designated path. The file is created if it does not exist.
+func NewBoltBackend(path string) Backend {
+	return &boltBackend{path: path}
+}
+
+// Open opens the bbolt file and creates buckets for the node collections designated by config.
+func (b *boltBackend) Open(ctx context.Context, config *Config) error {
+	db, err := bolt.Open(b.path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to open bolt database: %v", b.path)
+	}
+	b.db = db
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		nodes, err := tx.CreateBucketIfNotExists(boltNodesBucket)
+		if err != nil {
+			return err
+		}
+		for _, kind := range config.NodeCollections {
+			_, err := nodes.CreateBucketIfNotExists([]byte(kind))
+			if err != nil {
+				return errors.Wrapf(err, "sst: failed to create %v node bucket", kind)
+			}
+		}
+		links, err := tx.CreateBucketIfNotExists(boltLinksBucket)
+		if err != nil {
+			return err
+		}
+		for _, typ := range linkTypes(nil) {
+			_, err := links.CreateBucketIfNotExists([]byte(typ.String()))
+			if err != nil {
+				return errors.Wrapf(err, "sst: failed to create %v link bucket", typ)
+			}
+		}
+		_, err = tx.CreateBucketIfNotExists(boltAssociationsBucket)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(boltTimelinesBucket)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(boltEventsBucket)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(boltFromBucket)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(boltToBucket)
+		return err
+	})
+	if err != nil {
+		b.db.Close()
+		return errors.Wrapf(err, "sst: failed to create buckets in bolt database: %v", b.path)
+	}
+	return nil
+}
+
+// Close closes the bbolt file
+func (b *boltBackend) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Close()
+}
+
+// UpsertNode idempotently inserts the node into the bucket specified by node.Prefix
+func (b *boltBackend) UpsertNode(ctx context.Context, node *Node) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltUpsertNode(tx, node)
+	})
+}
+
+// UpsertNodes idempotently inserts the nodes in order within a single transaction
+func (b *boltBackend) UpsertNodes(ctx context.Context, nodes []*Node) error {
+	errs := make(BatchError, len(nodes))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for i, node := range nodes {
+			errs[i] = boltUpsertNode(tx, node)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errs.orNil()
+}
+
+// ReadNode reads the node with the designated _id
+func (b *boltBackend) ReadNode(ctx context.Context, id string) (*Node, error) {
+	var node *Node
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		node, err = boltIndex{tx}.readNode(id)
+		return err
+	})
+	return node, err
+}
+
+// RemoveNode removes the node with the designated _id if it exists
+func (b *boltBackend) RemoveNode(ctx context.Context, id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltRemoveNode(tx, id)
+	})
+}
+
+// ReadNodeCollections reads the names of the node buckets
+func (b *boltBackend) ReadNodeCollections(ctx context.Context) ([]string, error) {
+	names := make([]string, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNodesBucket).ForEach(func(k, v []byte) error {
+			if v == nil {
+				names = append(names, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// AddNodeCollection creates the named node bucket if it does not exist
+func (b *boltBackend) AddNodeCollection(ctx context.Context, name string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.Bucket(boltNodesBucket).CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to create %v node bucket", name)
+		}
+		return nil
+	})
+}
+
+// RemoveNodeCollection removes the named node bucket and its nodes
+func (b *boltBackend) RemoveNodeCollection(ctx context.Context, name string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(boltNodesBucket).DeleteBucket([]byte(name))
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return errors.Wrapf(err, "sst: failed to remove %v node bucket", name)
+		}
+		return nil
+	})
+}
+
+// ReadNodes reads the nodes of the named bucket ordered by _key
+func (b *boltBackend) ReadNodes(ctx context.Context, collection string) ([]*Node, error) {
+	nodes := make([]*Node, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		col, err := boltCollectionOf(tx, collection+"/")
+		if err != nil {
+			return err
+		}
+		return col.ForEach(func(k, v []byte) error {
+			node, err := decodeNode(v, collection+"/"+string(k))
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// ListNodes reads the page of the nodes of the named bucket selected by the filter
+func (b *boltBackend) ListNodes(ctx context.Context, collection string, filter *NodeFilter, page *Page) ([]*Node, error) {
+	nodes, err := b.ReadNodes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return selectNodes(nodes, filter, page), nil
+}
+
+// UpsertLink creates the link or executes the designated operation on the existing link
+func (b *boltBackend) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		link, err = boltUpsertLink(tx, typ, link, op)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// UpsertLinks creates the links or executes the designated operation on the
+// existing links in order within a single transaction
+func (b *boltBackend) UpsertLinks(ctx context.Context, types []SemanticType, links []*Link, op LinkOp) ([]*Link, error) {
+	stored := make([]*Link, len(links))
+	errs := make(BatchError, len(links))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for i, link := range links {
+			stored[i], errs[i] = boltUpsertLink(tx, types[i], link, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, errs.orNil()
+}
+
+// ReadLink reads the link with the designated key
+func (b *boltBackend) ReadLink(ctx context.Context, typ SemanticType, key string) (*Link, error) {
+	var link *Link
+	err := b.db.View(func(tx *bolt.Tx) error {
+		links, err := boltLinksOf(tx, typ)
+		if err != nil {
+			return err
+		}
+		link, err = boltReadLink(links, key)
+		return err
+	})
+	return link, err
+}
+
+// RemoveLink removes the link with the designated key if it exists
+func (b *boltBackend) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltRemoveLink(tx, typ, key)
+	})
+}
+
+// ReadLinks reads the links of the bucket of the designated SemanticType ordered by _key
+func (b *boltBackend) ReadLinks(ctx context.Context, typ SemanticType) ([]*Link, error) {
+	links := make([]*Link, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		col, err := boltLinksOf(tx, typ)
+		if err != nil {
+			return err
+		}
+		return col.ForEach(func(k, v []byte) error {
+			link, err := decodeLink(v, string(k))
+			if err != nil {
+				return err
+			}
+			links = append(links, link)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ReadAssociations reads all associations from the associations bucket
+func (b *boltBackend) ReadAssociations(ctx context.Context) (map[string]*Association, error) {
+	associations := make(map[string]*Association)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltAssociationsBucket).ForEach(func(k, v []byte) error {
+			var a Association
+			err := json.Unmarshal(v, &a)
+			if err != nil {
+				return errors.Wrapf(err, "sst: failed to read association: %s", k)
+			}
+			associations[a.Key] = &a
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return associations, nil
+}
+
+// InsertAssociation stores the association in the associations bucket unless
+// an association with the same key is already stored
+func (b *boltBackend) InsertAssociation(ctx context.Context, a *Association) (*Association, error) {
+	stored := a
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		associations := tx.Bucket(boltAssociationsBucket)
+		existing := associations.Get([]byte(a.Key))
+		if existing != nil {
+			stored = &Association{}
+			return json.Unmarshal(existing, stored)
+		}
+		doc, err := json.Marshal(a)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to create association: %v", a)
+		}
+		return associations.Put([]byte(a.Key), doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// ReadTimeline reads the head of the named timeline from the timelines bucket
+func (b *boltBackend) ReadTimeline(ctx context.Context, name string) ([]string, error) {
+	var timeline timelineDocument
+	err := b.db.View(func(tx *bolt.Tx) error {
+		stored := tx.Bucket(boltTimelinesBucket).Get([]byte(name))
+		if stored == nil {
+			return nil
+		}
+		return json.Unmarshal(stored, &timeline)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read timeline: %v", name)
+	}
+	return timeline.Head, nil
+}
+
+// WriteTimeline stores the head of the named timeline in the timelines bucket
+func (b *boltBackend) WriteTimeline(ctx context.Context, name string, head []string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltWriteTimeline(tx, name, head)
+	})
+}
+
+// AppendEvents records the observation of events in the bucket of the timeline
+func (b *boltBackend) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltAppendEvents(tx, timeline, events)
+	})
+}
+
+// ReadEvents reads events observed within the designated time range from the bucket of the timeline
+func (b *boltBackend) ReadEvents(ctx context.Context, timeline string, from, to time.Time) ([]*TimelineEvent, error) {
+	events := make([]*TimelineEvent, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		recorded := tx.Bucket(boltEventsBucket).Bucket([]byte(timeline))
+		if recorded == nil {
+			return nil
+		}
+		end := []byte(formatEventTime(to))
+		c := recorded.Cursor()
+		for k, _ := c.Seek([]byte(formatEventTime(from))); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Next() {
+			e, err := boltEvent(k)
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ReadActiveEvents reads the latest events observed at or before the designated time from the bucket of the timeline
+func (b *boltBackend) ReadActiveEvents(ctx context.Context, timeline string, at time.Time) ([]*TimelineEvent, error) {
+	events := make([]*TimelineEvent, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		recorded := tx.Bucket(boltEventsBucket).Bucket([]byte(timeline))
+		if recorded == nil {
+			return nil
+		}
+		c := recorded.Cursor()
+		k, _ := c.Seek([]byte(formatEventTime(at.Add(time.Nanosecond))))
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+		var latest time.Time
+		for ; k != nil; k, _ = c.Prev() {
+			e, err := boltEvent(k)
+			if err != nil {
+				return err
+			}
+			if len(events) > 0 && !e.Time.Equal(latest) {
+				break
+			}
+			latest = e.Time
+			events = append([]*TimelineEvent{e}, events...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Begin starts a writable bbolt transaction. bbolt allows a single writable
+// transaction at a time, other writes wait until it is committed or aborted.
+func (b *boltBackend) Begin(ctx context.Context) (BackendTx, error) {
+	tx, err := b.db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to begin transaction")
+	}
+	return &boltTx{tx: tx}, nil
+}
+
+// Traverse walks the graph breadth-first using the from and to indexes
+func (b *boltBackend) Traverse(ctx context.Context, startID string, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	opts = traversalOptions(opts)
+	var nodes []*Node
+	var links []*Link
+	err := b.db.View(func(tx *bolt.Tx) error {
+		for _, typ := range opts.SemanticTypes {
+			if _, err := boltLinksOf(tx, typ); err != nil {
+				return err
+			}
+		}
+		var err error
+		nodes, links, err = walk(boltIndex{tx}, startID, opts)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, links, nil
+}
+
+// ApplySchema does nothing, the bolt backend has no schema validation
+func (b *boltBackend) ApplySchema(ctx context.Context, schema *Schema, associations []*Association) error {
+	return nil
+}
+
+// Query is not supported by the bolt backend
+func (b *boltBackend) Query(ctx context.Context, query string, vars map[string]interface{}) (driver.Cursor, error) {
+	return nil, unsupportedQuery
+}
+
+// adjacent returns the links from or to the node with the designated _id
+func (x boltIndex) adjacent(id string, dir Direction) ([]linkRef, error) {
+	refs := make([]linkRef, 0)
+	if dir == Outbound || dir == Any {
+		refs = append(refs, boltScanIndex(x.tx.Bucket(boltFromBucket), id)...)
+	}
+	if dir == Inbound || dir == Any {
+		refs = append(refs, boltScanIndex(x.tx.Bucket(boltToBucket), id)...)
+	}
+	return refs, nil
+}
+
+// readLinkRef reads the designated link
+func (x boltIndex) readLinkRef(ref linkRef) (*Link, error) {
+	links, err := boltLinksOf(x.tx, ref.typ)
+	if err != nil {
+		return nil, err
+	}
+	return boltReadLink(links, ref.key)
+}
+
+// readNode reads the node with the designated _id
+func (x boltIndex) readNode(id string) (*Node, error) {
+	kind, key := splitNodeID(id)
+	nodes, err := boltCollectionOf(x.tx, kind+"/")
+	if err != nil {
+		return nil, err
+	}
+	stored := nodes.Get([]byte(key))
+	if stored == nil {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no node for id: %v", id)
+	}
+	return decodeNode(stored, id)
+}
+
+// boltUpsertNode idempotently inserts the node within the transaction
+func boltUpsertNode(tx *bolt.Tx, node *Node) error {
+	nodes, err := boltCollectionOf(tx, node.Prefix)
+	if err != nil {
+		return err
+	}
+	doc, err := upsertNodeDocument(nodes.Get([]byte(node.Key)), node)
+	if err != nil || doc == nil {
+		return err
+	}
+	return nodes.Put([]byte(node.Key), doc)
+}
+
+// boltUpsertLink creates the link or executes op on the existing link within the transaction
+func boltUpsertLink(tx *bolt.Tx, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	links, err := boltLinksOf(tx, typ)
+	if err != nil {
+		return nil, err
+	}
+	stored := links.Get([]byte(link.Key))
+	doc, link, err := upsertLinkDocument(stored, link, op)
+	if err != nil || doc == nil {
+		return link, err
+	}
+	err = links.Put([]byte(link.Key), doc)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		err = tx.Bucket(boltFromBucket).Put(boltIndexKey(link.From, typ, link.Key), []byte{})
+		if err != nil {
+			return nil, err
+		}
+		err = tx.Bucket(boltToBucket).Put(boltIndexKey(link.To, typ, link.Key), []byte{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return link, nil
+}
+
+// boltRemoveNode removes the node if it exists within the transaction
+func boltRemoveNode(tx *bolt.Tx, id string) error {
+	kind, key := splitNodeID(id)
+	nodes, err := boltCollectionOf(tx, kind+"/")
+	if err != nil {
+		return err
+	}
+	return nodes.Delete([]byte(key))
+}
+
+// boltRemoveLink removes the link if it exists within the transaction
+func boltRemoveLink(tx *bolt.Tx, typ SemanticType, key string) error {
+	links, err := boltLinksOf(tx, typ)
+	if err != nil {
+		return err
+	}
+	link, err := boltReadLink(links, key)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = links.Delete([]byte(key))
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(boltFromBucket).Delete(boltIndexKey(link.From, typ, key))
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltToBucket).Delete(boltIndexKey(link.To, typ, key))
+}
+
+// boltWriteTimeline stores the head of the named timeline within the transaction
+func boltWriteTimeline(tx *bolt.Tx, name string, head []string) error {
+	doc, err := json.Marshal(&timelineDocument{Key: name, Head: head})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to write timeline: %v", name)
+	}
+	return tx.Bucket(boltTimelinesBucket).Put([]byte(name), doc)
+}
+
+// boltAppendEvents records the observation of events within the transaction
+func boltAppendEvents(tx *bolt.Tx, timeline string, events []*TimelineEvent) error {
+	recorded, err := tx.Bucket(boltEventsBucket).CreateBucketIfNotExists([]byte(timeline))
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to record events of timeline: %v", timeline)
+	}
+	for _, e := range events {
+		key := bytes.Join([][]byte{[]byte(formatEventTime(e.Time)), []byte(e.ID)}, boltSeparator)
+		err := recorded.Put(key, []byte{})
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to record events of timeline: %v", timeline)
+		}
+	}
+	return nil
+}
+
+// boltTx executes writes within a writable bbolt transaction
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// UpsertNode idempotently inserts the node within the transaction
+func (t *boltTx) UpsertNode(ctx context.Context, node *Node) error {
+	return boltUpsertNode(t.tx, node)
+}
+
+// RemoveNode removes the node with the designated _id if it exists within the transaction
+func (t *boltTx) RemoveNode(ctx context.Context, id string) error {
+	return boltRemoveNode(t.tx, id)
+}
+
+// UpsertLink creates the link or executes the designated operation on the existing link within the transaction
+func (t *boltTx) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	return boltUpsertLink(t.tx, typ, link, op)
+}
+
+// RemoveLink removes the link with the designated key if it exists within the transaction
+func (t *boltTx) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	return boltRemoveLink(t.tx, typ, key)
+}
+
+// WriteTimeline stores the head of the named timeline within the transaction
+func (t *boltTx) WriteTimeline(ctx context.Context, name string, head []string) error {
+	return boltWriteTimeline(t.tx, name, head)
+}
+
+// AppendEvents records the observation of events within the transaction
+func (t *boltTx) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	return boltAppendEvents(t.tx, timeline, events)
+}
+
+// Commit commits the bbolt transaction
+func (t *boltTx) Commit(ctx context.Context) error {
+	err := t.tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to commit transaction")
+	}
+	return nil
+}
+
+// Abort rolls back the bbolt transaction
+func (t *boltTx) Abort(ctx context.Context) error {
+	err := t.tx.Rollback()
+	if err != nil && err != bolt.ErrTxClosed {
+		return errors.Wrap(err, "sst: failed to abort transaction")
+	}
+	return nil
+}
+
+// boltCollectionOf identifies node bucket based on node prefix
+func boltCollectionOf(tx *bolt.Tx, prefix string) (*bolt.Bucket, error) {
+	var col *bolt.Bucket
+	if len(prefix) > 0 {
+		col = tx.Bucket(boltNodesBucket).Bucket([]byte(prefix[:len(prefix)-1]))
+	}
+	if col == nil {
+		return nil, errors.New(fmt.Sprintf("sst: no node collection for prefix: %v", prefix))
+	}
+	return col, nil
+}
+
+// boltLinksOf identifies links bucket based on SemanticType needed
+func boltLinksOf(tx *bolt.Tx, typ SemanticType) (*bolt.Bucket, error) {
+	col := tx.Bucket(boltLinksBucket).Bucket([]byte(typ.abs().String()))
+	if col == nil {
+		return nil, errors.New(fmt.Sprintf("sst: no link collection for semantic type: %v", int(typ)))
+	}
+	return col, nil
+}
+
+// boltReadLink decodes the link with the designated key from the links bucket
+func boltReadLink(links *bolt.Bucket, key string) (*Link, error) {
+	stored := links.Get([]byte(key))
+	if stored == nil {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no link for key: %v", key)
+	}
+	return decodeLink(stored, key)
+}
+
+// boltEvent decodes the event from its key in the bucket of a timeline
+func boltEvent(key []byte) (*TimelineEvent, error) {
+	parts := bytes.SplitN(key, boltSeparator, 2)
+	if len(parts) != 2 {
+		return nil, errors.New(fmt.Sprintf("sst: invalid event key: %q", key))
+	}
+	return (&eventDocument{Time: string(parts[0]), Node: string(parts[1])}).event()
+}
+
+// boltIndexKey creates the from or to index key of a link
+func boltIndexKey(id string, typ SemanticType, key string) []byte {
+	return bytes.Join([][]byte{[]byte(id), []byte(typ.abs().String()), []byte(key)}, boltSeparator)
+}
+
+// boltScanIndex returns the links indexed for the node with the designated _id
+func boltScanIndex(index *bolt.Bucket, id string) []linkRef {
+	refs := make([]linkRef, 0)
+	prefix := append([]byte(id), boltSeparator...)
+	c := index.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		parts := bytes.SplitN(k[len(prefix):], boltSeparator, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		refs = append(refs, linkRef{typ: boltLinkTypes[string(parts[0])], key: string(parts[1])})
+	}
+	return refs
+}
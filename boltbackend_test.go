@@ -0,0 +1,109 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func boltSST(t *testing.T, path string) *SST {
+	s, err := NewSST(&Config{
+		Backend:         NewBoltBackend(path),
+		Name:            "bolt",
+		NodeCollections: []string{"Node"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	return s
+}
+
+func TestBoltPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sst.db")
+	s := boltSST(t, path)
+	n1 := s.MustCreateNode("Node", "from_node", map[string]interface{}{"some": "data"}, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+	s.MustCreateLink(n1, "contains", n2, nil, 1)
+	assert.NoError(t, s.Close())
+
+	s = boltSST(t, path)
+	defer s.Close()
+	data, err := s.GetNodeData("Node/from_node")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"some": "data"}, data)
+
+	link, err := s.backend.ReadLink(context.TODO(), Contains, "+Node%2Ffrom_nodecontainsNode%2Fto_node")
+	assert.NoError(t, err)
+	assert.Equal(t, &Link{
+		Key:    "+Node%2Ffrom_nodecontainsNode%2Fto_node",
+		From:   "Node/from_node",
+		To:     "Node/to_node",
+		SID:    "contains",
+		Weight: 1,
+	}, link)
+}
+
+func TestBoltIncrementAndDeleteLink(t *testing.T) {
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	n1 := s.MustCreateNode("Node", "from_node", nil, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+
+	s.MustIncrementLink(n1, "related", n2, nil)
+	link := s.MustIncrementLink(n1, "related", n2, nil)
+	assert.Equal(t, 1.0, link.Weight)
+
+	s.MustDeleteLink(n1, "related", n2, false)
+	_, err := s.backend.ReadLink(context.TODO(), Near, link.Key)
+	assert.True(t, IsNotFound(err))
+	nodes, links, err := s.backend.Traverse(context.TODO(), "Node/from_node", &TraversalOptions{Direction: Any, Depth: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+	assert.Empty(t, links)
+}
+
+func TestBoltTraverse(t *testing.T) {
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	n1 := s.MustCreateNode("Node", "n1", nil, 1)
+	n2 := s.MustCreateNode("Node", "n2", nil, 1)
+	n3 := s.MustCreateNode("Node", "n3", nil, 1)
+	s.MustCreateLink(n1, "contains", n2, nil, 1)
+	s.MustCreateLink(n2, "contains", n3, nil, 1)
+	s.MustCreateLink(n1, "related", n3, nil, 1)
+
+	nodes, links, err := s.backend.Traverse(context.TODO(), "Node/n1", &TraversalOptions{SemanticTypes: []SemanticType{Contains}, Direction: Outbound, Depth: 2})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "n2", nodes[0].Key)
+	assert.Equal(t, "n3", nodes[1].Key)
+	assert.Len(t, links, 2)
+
+	nodes, links, err = s.backend.Traverse(context.TODO(), "Node/n3", &TraversalOptions{Direction: Inbound, Depth: 1})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Len(t, links, 2)
+}
+
+func TestBoltAssociations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sst.db")
+	s := boltSST(t, path)
+	visa := &Association{Key: "Visa Waiver", SemanticType: Expresses, Fwd: "grants visa to", Bwd: "holds visa from", Nfwd: "did not grant visa to", Nbwd: "does not hold visa from"}
+	assert.NoError(t, s.CreateAssociation(visa))
+	assert.NoError(t, s.Close())
+
+	s = boltSST(t, path)
+	defer s.Close()
+	assert.Equal(t, visa, s.associations["Visa_Waiver"])
+	assert.Equal(t, associations["then"], s.associations["then"])
+
+	again := *visa
+	assert.NoError(t, s.CreateAssociation(&again))
+	again.Fwd = "grants a visa to"
+	err := s.CreateAssociation(&again)
+	assert.Error(t, err)
+	assert.Equal(t, associationConflict, errors.Cause(err))
+}
@@ -0,0 +1,53 @@
+// Command sst-server serves the REST API of a Semantic Spacetime.
+//
+// Usage:
+//
+//	sst-server [flags]
+//
+// The API is described by the OpenAPI document served at /openapi.json. The
+// spacetime is stored in ArangoDB unless the -bolt flag designates a bbolt file.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tristanls/sst"
+	"github.com/tristanls/sst/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	bolt := flag.String("bolt", "", "path of a bbolt file to store the spacetime in instead of ArangoDB")
+	collections := flag.String("collections", "Node", "comma separated node collections")
+	name := flag.String("name", "semantic_spacetime", "ArangoDB database name")
+	password := flag.String("password", os.Getenv("SST_PASSWORD"), "ArangoDB password, defaults to $SST_PASSWORD")
+	url := flag.String("url", "http://localhost:8529", "ArangoDB URL")
+	username := flag.String("username", "root", "ArangoDB username")
+	flag.Parse()
+
+	config := &sst.Config{
+		Name:            *name,
+		NodeCollections: strings.Split(*collections, ","),
+		Password:        *password,
+		URL:             *url,
+		Username:        *username,
+	}
+	if *bolt != "" {
+		config.Backend = sst.NewBoltBackend(*bolt)
+	}
+	s, err := sst.NewSST(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	log.Printf("sst-server: listening on %v", *addr)
+	err = http.ListenAndServe(*addr, server.New(s))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,476 @@
+// Command sst inspects and edits a Semantic Spacetime.
+//
+// Usage:
+//
+//	sst [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	collections                                     list node collections
+//	associations                                    list associations
+//	list [-prefix p] [-offset n] [-limit n] <kind>  list the nodes of a collection
+//	show <id>                                       show a node and its neighbours
+//	create-node [-data json] [-weight w] <kind> <key>
+//	                                                create a node
+//	delete-node [-cascade] <id>                     delete a node
+//	create-link [-data json] [-weight w] [-block] <from id> <association> <to id>
+//	                                                create or block a link
+//	delete-link [-negated] <from id> <association> <to id>
+//	                                                delete a link
+//	timeline [-from time] [-to time] <name>         walk the events of a timeline
+//	dot [-depth n] [-negated] [id]                  write the graph, or the neighbourhood
+//	                                                of a node, in GraphViz DOT format
+//	export [-format json|graphml|turtle] [-base iri]
+//	                                                write the whole spacetime
+//	import [-format json|graphml|turtle] [-base iri] [file]
+//	                                                import a spacetime written by export
+//	migrate-keys                                    re-key the nodes stored before keys encoded names
+//
+// Nodes are designated by their _id, as in "Node/Paris", or by their collection
+// and name, as in "Node/New York". The spacetime is stored in ArangoDB unless
+// the -bolt flag designates a bbolt file. The -collections flag adds node
+// collections to the spacetime, stored node collections are never removed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tristanls/sst"
+)
+
+// defaultBase is the default base IRI of exported RDF
+const defaultBase = "urn:sst:"
+
+var (
+	invalidNodeID = errors.New("sst: invalid node id")
+	usageError    = errors.New("sst: invalid usage")
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Cause(err) == usageError {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes the command designated by args
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("sst", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	bolt := flags.String("bolt", "", "path of a bbolt file to store the spacetime in instead of ArangoDB")
+	collections := flags.String("collections", "", "comma separated node collections to add, none by default")
+	name := flags.String("name", "semantic_spacetime", "ArangoDB database name")
+	password := flags.String("password", os.Getenv("SST_PASSWORD"), "ArangoDB password, defaults to $SST_PASSWORD")
+	url := flags.String("url", "http://localhost:8529", "ArangoDB URL")
+	username := flags.String("username", "root", "ArangoDB username")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: sst [flags] <collections|associations|list|show|create-node|delete-node|create-link|delete-link|timeline|dot|export|import|migrate-keys> [arguments]")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return usageError
+	}
+
+	// The store is opened with its stored node collections, not reconciled, so
+	// that inspecting it does not change it
+	config := &sst.Config{
+		Name:     *name,
+		Password: *password,
+		URL:      *url,
+		Username: *username,
+	}
+	if *collections != "" {
+		config.NodeCollections = strings.Split(*collections, ",")
+	}
+	if *bolt != "" {
+		config.Backend = sst.NewBoltBackend(*bolt)
+	}
+	s, err := sst.NewSST(config)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cmd, cmdArgs := flags.Arg(0), flags.Args()[1:]
+	switch cmd {
+	case "collections":
+		return listCollections(s, cmdArgs, stdout)
+	case "associations":
+		return listAssociations(s, cmdArgs, stdout)
+	case "list":
+		return listNodes(s, cmdArgs, stdout, stderr)
+	case "show":
+		return showNode(s, cmdArgs, stdout)
+	case "create-node":
+		return createNode(s, cmdArgs, stdout, stderr)
+	case "delete-node":
+		return deleteNode(s, cmdArgs, stderr)
+	case "create-link":
+		return createLink(s, cmdArgs, stdout, stderr)
+	case "delete-link":
+		return deleteLink(s, cmdArgs, stderr)
+	case "timeline":
+		return walkTimeline(s, cmdArgs, stdout, stderr)
+	case "dot":
+		return writeDOT(s, cmdArgs, stdout, stderr)
+	case "export":
+		return exportGraph(s, cmdArgs, stdout, stderr)
+	case "import":
+		return importGraph(s, cmdArgs, stdin, stderr)
+	case "migrate-keys":
+		return migrateKeys(s, cmdArgs, stdout)
+	}
+	flags.Usage()
+	return errors.Wrapf(usageError, "sst: unknown command: %v", cmd)
+}
+
+func listCollections(s *sst.SST, args []string, stdout io.Writer) error {
+	collections, err := s.NodeCollections()
+	if err != nil {
+		return err
+	}
+	for _, name := range collections {
+		fmt.Fprintln(stdout, name)
+	}
+	return nil
+}
+
+func listAssociations(s *sst.SST, args []string, stdout io.Writer) error {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tFORWARD\tBACKWARD")
+	for _, a := range s.Associations() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Key, a.SemanticType, a.Fwd, a.Bwd)
+	}
+	return w.Flush()
+}
+
+func listNodes(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	prefix := flags.String("prefix", "", "prefix of the names of listed nodes")
+	offset := flags.Int("offset", 0, "number of nodes skipped")
+	limit := flags.Int("limit", 0, "maximum number of nodes, all if 0")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.Wrap(usageError, "usage: sst list [-prefix p] [-offset n] [-limit n] <kind>")
+	}
+	nodes, err := s.ListNodes(flags.Arg(0), &sst.NodeFilter{NamePrefix: *prefix}, &sst.Page{Offset: *offset, Limit: *limit})
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		fmt.Fprintln(stdout, sst.MustNodeID(node))
+	}
+	return nil
+}
+
+func showNode(s *sst.SST, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return errors.Wrap(usageError, "usage: sst show <id>")
+	}
+	node, err := nodeOf(args[0])
+	if err != nil {
+		return err
+	}
+	stored, err := s.GetNode(node.Prefix + node.Label())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, sst.MustNodeID(stored))
+	if len(stored.Data) > 0 {
+		encoded, err := json.MarshalIndent(stored.Data, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(encoded))
+	}
+	_, links, err := s.Traverse(node, &sst.TraversalOptions{Direction: sst.Any, Depth: 1, IncludeNegated: true})
+	if err != nil {
+		return err
+	}
+	if len(links) > 0 {
+		text, err := s.RenderNeighbourhood(node, links)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, text)
+	}
+	return nil
+}
+
+func createNode(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("create-node", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	data := flags.String("data", "", "JSON object of node data")
+	weight := flags.Float64("weight", 1, "node weight")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.Wrap(usageError, "usage: sst create-node [-data json] [-weight w] <kind> <key>")
+	}
+	decoded, err := decodeData(*data)
+	if err != nil {
+		return err
+	}
+	node, err := s.CreateNode(flags.Arg(0), flags.Arg(1), decoded, *weight)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, sst.MustNodeID(node))
+	return nil
+}
+
+func deleteNode(s *sst.SST, args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("delete-node", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	cascade := flags.Bool("cascade", false, "delete links of the node with the node")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.Wrap(usageError, "usage: sst delete-node [-cascade] <id>")
+	}
+	node, err := nodeOf(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return s.DeleteNode(node, *cascade)
+}
+
+func createLink(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("create-link", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	block := flags.Bool("block", false, "create the negation of the link")
+	data := flags.String("data", "", "JSON object of link data")
+	weight := flags.Float64("weight", 1, "link weight")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 3 {
+		return errors.Wrap(usageError, "usage: sst create-link [-data json] [-weight w] [-block] <from id> <association> <to id>")
+	}
+	decoded, err := decodeData(*data)
+	if err != nil {
+		return err
+	}
+	from, err := nodeOf(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := nodeOf(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	var link *sst.Link
+	if *block {
+		link, err = s.BlockLink(from, flags.Arg(1), to, decoded, *weight)
+	} else {
+		link, err = s.CreateLink(from, flags.Arg(1), to, decoded, *weight)
+	}
+	if err != nil {
+		return err
+	}
+	text, err := s.RenderLink(link, true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, text)
+	return nil
+}
+
+func deleteLink(s *sst.SST, args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("delete-link", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	negated := flags.Bool("negated", false, "delete the negation of the link")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 3 {
+		return errors.Wrap(usageError, "usage: sst delete-link [-negated] <from id> <association> <to id>")
+	}
+	from, err := nodeOf(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := nodeOf(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	return s.DeleteLink(from, flags.Arg(1), to, *negated)
+}
+
+func walkTimeline(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("timeline", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "", "RFC 3339 time of the earliest event to walk")
+	to := flags.String("to", "", "RFC 3339 time after the latest event to walk")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.Wrap(usageError, "usage: sst timeline [-from time] [-to time] <name>")
+	}
+	start, end := time.Time{}, time.Now().Add(24*time.Hour*365*100)
+	if *from != "" {
+		start, err = time.Parse(time.RFC3339Nano, *from)
+		if err != nil {
+			return errors.Wrap(usageError, err.Error())
+		}
+	}
+	if *to != "" {
+		end, err = time.Parse(time.RFC3339Nano, *to)
+		if err != nil {
+			return errors.Wrap(usageError, err.Error())
+		}
+	}
+	t, err := s.Timeline(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	events, err := t.Events(start, end)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\n", e.Time.Format(time.RFC3339Nano), sst.MustNodeID(e))
+	}
+	return w.Flush()
+}
+
+func writeDOT(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dot", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	depth := flags.Int("depth", 1, "maximum number of links away from the node")
+	negated := flags.Bool("negated", false, "walk negated links as well")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	switch flags.NArg() {
+	case 0:
+		return s.WriteDOT(stdout)
+	case 1:
+		node, err := nodeOf(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		return s.WriteNeighbourhoodDOT(stdout, node, &sst.TraversalOptions{Direction: sst.Any, Depth: *depth, IncludeNegated: *negated})
+	}
+	return errors.Wrap(usageError, "usage: sst dot [-depth n] [-negated] [id]")
+}
+
+func exportGraph(s *sst.SST, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	base := flags.String("base", defaultBase, "base IRI of nodes and associations in turtle format")
+	format := flags.String("format", "json", "json, graphml or turtle")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.Wrap(usageError, "usage: sst export [-format json|graphml|turtle] [-base iri]")
+	}
+	switch *format {
+	case "json":
+		return s.WriteJSON(stdout)
+	case "graphml":
+		return s.WriteGraphML(stdout)
+	case "turtle":
+		return s.WriteTurtle(stdout, *base)
+	}
+	return errors.Wrapf(usageError, "sst: unknown format: %v", *format)
+}
+
+func importGraph(s *sst.SST, args []string, stdin io.Reader, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	base := flags.String("base", defaultBase, "base IRI of nodes and associations in turtle format")
+	format := flags.String("format", "json", "json, graphml or turtle")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.Wrap(usageError, "usage: sst import [-format json|graphml|turtle] [-base iri] [file]")
+	}
+	r := stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	switch *format {
+	case "json":
+		return s.ImportJSON(r)
+	case "graphml":
+		return s.ImportGraphML(r)
+	case "turtle":
+		return s.ImportTurtle(r, *base)
+	}
+	return errors.Wrapf(usageError, "sst: unknown format: %v", *format)
+}
+
+func migrateKeys(s *sst.SST, args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		return errors.Wrap(usageError, "usage: sst migrate-keys")
+	}
+	migrated, err := s.MigrateDocumentKeys()
+	fmt.Fprintf(stdout, "migrated %d nodes\n", migrated)
+	return err
+}
+
+// nodeOf returns the node designated by the _id
+func nodeOf(id string) (*sst.Node, error) {
+	i := strings.Index(id, "/")
+	if i < 1 || i == len(id)-1 {
+		return nil, errors.Wrapf(invalidNodeID, "sst: node id must be <collection>/<key>: %v", id)
+	}
+	return &sst.Node{Prefix: id[:i+1], Key: id[i+1:]}, nil
+}
+
+// decodeData decodes the JSON object of node or link data
+func decodeData(data string) (map[string]interface{}, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var decoded map[string]interface{}
+	err := json.Unmarshal([]byte(data), &decoded)
+	if err != nil {
+		return nil, errors.Wrapf(usageError, "sst: data must be a JSON object: %v", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sst.db")
+	sstWith := func(path, stdin string, args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(append([]string{"-bolt", path, "-collections", "Node,Hub"}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), err
+	}
+	sst := func(args ...string) (string, error) {
+		return sstWith(path, "", args...)
+	}
+
+	out, err := sst("create-node", "-data", `{"description":"capital of France"}`, "Hub", "Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hub/Paris\n", out)
+	_, err = sst("create-node", "Hub", "France")
+	assert.NoError(t, err)
+	out, err = sst("create-link", "Hub/Paris", "part_of", "Hub/France")
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris is part of France\n", out)
+
+	out, err = sst("show", "Hub/France")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hub/France\nFrance incorporates Paris.\n", out)
+	out, err = sst("show", "Hub/Paris")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"description": "capital of France"`)
+
+	out, err = sst("list", "-prefix", "P", "Hub")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hub/Paris\n", out)
+	out, err = sst("list", "-offset", "1", "Hub")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hub/Paris\n", out)
+
+	out, err = sst("collections")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hub\nNode\n", out)
+	out, err = sst("associations")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "part_of")
+	out, err = sst("dot", "-depth", "2", "Hub/France")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"Hub/Paris" -> "Hub/France" [label="is part of"`)
+
+	for _, format := range []string{"json", "graphml", "turtle"} {
+		exported, err := sst("export", "-format", format)
+		assert.NoError(t, err)
+		copyPath := filepath.Join(t.TempDir(), "copy.db")
+		_, err = sstWith(copyPath, exported, "import", "-format", format)
+		assert.NoError(t, err)
+		out, err = sstWith(copyPath, "", "export", "-format", format)
+		assert.NoError(t, err)
+		assert.Equal(t, exported, out)
+	}
+
+	_, err = sst("delete-node", "Hub/Paris")
+	assert.Error(t, err)
+	_, err = sst("delete-link", "Hub/Paris", "part_of", "Hub/France")
+	assert.NoError(t, err)
+	_, err = sst("delete-node", "Hub/Paris")
+	assert.NoError(t, err)
+
+	_, err = sst("create-link", "Hub/Paris", "part_of")
+	assert.Equal(t, usageError, errors.Cause(err))
+	_, err = sst("unknown")
+	assert.Equal(t, usageError, errors.Cause(err))
+
+	out, err = sst("create-node", "Node", "New York")
+	assert.NoError(t, err)
+	assert.Equal(t, "Node/New%20York\n", out)
+	out, err = sst("show", "Node/New York")
+	assert.NoError(t, err)
+	assert.Equal(t, "Node/New%20York\n", out)
+	_, err = sst("create-node", "Node", "Paris")
+	assert.NoError(t, err)
+	out, err = sst("create-link", "Node/New York", "related", "Node/Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, "New York may be related to Paris\n", out)
+	out, err = sst("show", "Node/Paris")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "New York")
+	_, err = sst("delete-link", "Node/New York", "related", "Node/Paris")
+	assert.NoError(t, err)
+	out, err = sst("show", "Node/Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, "Node/Paris\n", out)
+	out, err = sst("migrate-keys")
+	assert.NoError(t, err)
+	assert.Equal(t, "migrated 0 nodes\n", out)
+
+	out, err = sst("timeline", "default")
+	assert.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(out))
+}
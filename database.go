@@ -13,16 +13,15 @@ var (
 )
 
 // createDatabase creates a new ArangoDB database if it does not exist.
-func (s *SST) createDatabase(name string) (arango.Database, error) {
-	ctx := context.Background()
-	exists, err := s.client.DatabaseExists(ctx, name)
+func (b *arangoBackend) createDatabase(ctx context.Context, name string) (arango.Database, error) {
+	exists, err := b.client.DatabaseExists(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
 		return nil, databaseAlreadyExists
 	}
-	db, err := s.client.CreateDatabase(ctx, name, nil)
+	db, err := b.client.CreateDatabase(ctx, name, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, "sst: failed to create database: %v", name)
 	}
@@ -30,16 +29,15 @@ func (s *SST) createDatabase(name string) (arango.Database, error) {
 }
 
 // openDatabase opens an ArangoDB database if it exists.
-func (s *SST) openDatabase(name string) (arango.Database, error) {
-	ctx := context.Background()
-	exists, err := s.client.DatabaseExists(ctx, name)
+func (b *arangoBackend) openDatabase(ctx context.Context, name string) (arango.Database, error) {
+	exists, err := b.client.DatabaseExists(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
 		return nil, databaseDoesNotExist
 	}
-	db, err := s.client.Database(ctx, name)
+	db, err := b.client.Database(ctx, name)
 	if err != nil {
 		return nil, err
 	}
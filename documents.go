@@ -0,0 +1,132 @@
+package sst
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// mergeDocument applies the patch to the existing JSON document the way ArangoDB
+// updates documents: top level attributes are replaced and nested objects are merged.
+func mergeDocument(existing []byte, patch interface{}) ([]byte, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(existing, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to decode document")
+	}
+	encoded, err := json.Marshal(patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to encode document patch")
+	}
+	var changes map[string]interface{}
+	err = json.Unmarshal(encoded, &changes)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to decode document patch")
+	}
+	mergeObjects(doc, changes)
+	return json.Marshal(doc)
+}
+
+// mergeObjects merges src into dst recursively
+func mergeObjects(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if srcObj, ok := v.(map[string]interface{}); ok {
+			if dstObj, ok := dst[k].(map[string]interface{}); ok {
+				mergeObjects(dstObj, srcObj)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// upsertNodeDocument determines the JSON document to store when idempotently
+// inserting the node over the stored document, which is nil if the node does not
+// exist. Returns nil document if the stored document is to be kept.
+func upsertNodeDocument(stored []byte, node *Node) ([]byte, error) {
+	if stored == nil {
+		doc, err := json.Marshal(node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to create node: %v", node)
+		}
+		return doc, nil
+	}
+	var existing Node
+	err := json.Unmarshal(stored, &existing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read node: %v", node.Key)
+	}
+	if node.Data == nil && node.Weight == 0.0 && node.Time == nil {
+		if node.Name == "" || existing.Name != "" {
+			return nil, nil // Do not update the node if there is no data to enter
+		}
+		return mergeDocument(stored, map[string]string{"name": node.Name})
+	}
+	if !nodeChanged(&existing, node) {
+		return nil, nil
+	}
+	doc, err := mergeDocument(stored, node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to update node: %v", node)
+	}
+	return doc, nil
+}
+
+// nodeChanged returns true if the node carries weight, data, name or time different from the existing node
+func nodeChanged(existing, node *Node) bool {
+	if existing.Weight != node.Weight || !reflect.DeepEqual(existing.Data, node.Data) {
+		return true
+	}
+	if node.Name != "" && node.Name != existing.Name {
+		return true
+	}
+	return node.Time != nil && (existing.Time == nil || !existing.Time.Equal(*node.Time))
+}
+
+// upsertLinkDocument determines the JSON document to store when creating the link
+// or executing op over the stored document, which is nil if the link does not exist.
+// Returns nil document if the stored document is to be kept.
+func upsertLinkDocument(stored []byte, link *Link, op LinkOp) ([]byte, *Link, error) {
+	if stored == nil {
+		doc, err := json.Marshal(link)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "sst: failed to add new link: %v", link)
+		}
+		return doc, link, nil
+	}
+	var existing Link
+	err := json.Unmarshal(stored, &existing)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "sst: failed to read link: %v", link.Key)
+	}
+	updated, noop := op.apply(&existing, link)
+	if noop {
+		return nil, updated, nil
+	}
+	doc, err := mergeDocument(stored, updated)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "sst: failed to update link: %v", updated)
+	}
+	return doc, updated, nil
+}
+
+// decodeNode decodes the stored JSON node document
+func decodeNode(stored []byte, id string) (*Node, error) {
+	var node Node
+	err := json.Unmarshal(stored, &node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read node: %v", id)
+	}
+	return &node, nil
+}
+
+// decodeLink decodes the stored JSON link document
+func decodeLink(stored []byte, key string) (*Link, error) {
+	var link Link
+	err := json.Unmarshal(stored, &link)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to read link: %v", key)
+	}
+	return &link, nil
+}
@@ -0,0 +1,118 @@
+package sst
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// dotColors are the fill colors of nodes, cycled through by node collection
+var dotColors = []string{"lightblue", "lightyellow", "palegreen", "pink", "lightgrey", "wheat", "plum", "lightcyan"}
+
+// dotLinkColors are the colors of links by SemanticType
+var dotLinkColors = map[SemanticType]string{
+	Near:      "grey40",
+	Follows:   "blue",
+	Contains:  "darkgreen",
+	Expresses: "darkorange",
+}
+
+// WriteDOT writes the whole graph in GraphViz DOT format. Nodes are grouped in
+// a cluster per node collection and filled with a color per collection. Links
+// are colored by SemanticType, labelled with the forward phrase of their
+// association, dashed if negated and drawn thicker the heavier they are.
+func (s *SST) WriteDOT(w io.Writer) error {
+	return s.WriteDOTContext(context.Background(), w)
+}
+
+// WriteDOTContext invokes WriteDOT using the provided context
+func (s *SST) WriteDOTContext(ctx context.Context, w io.Writer) error {
+	nodes, links, err := s.readGraph(ctx)
+	if err != nil {
+		return err
+	}
+	return s.writeDOT(w, nodes, links)
+}
+
+// WriteNeighbourhoodDOT writes the node, the nodes reached by walking links
+// designated by opts, the defaults if nil, from the node and the walked links
+// in GraphViz DOT format, see WriteDOT.
+func (s *SST) WriteNeighbourhoodDOT(w io.Writer, node *Node, opts *TraversalOptions) error {
+	return s.WriteNeighbourhoodDOTContext(context.Background(), w, node, opts)
+}
+
+// WriteNeighbourhoodDOTContext invokes WriteNeighbourhoodDOT using the provided context
+func (s *SST) WriteNeighbourhoodDOTContext(ctx context.Context, w io.Writer, node *Node, opts *TraversalOptions) error {
+	id, err := NodeID(node)
+	if err != nil {
+		return err
+	}
+	nodes, links, err := s.backend.Traverse(ctx, id, opts)
+	if err != nil {
+		return err
+	}
+	start, err := s.backend.ReadNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	start.Prefix = node.Prefix
+	return s.writeDOT(w, append([]*Node{start}, nodes...), links)
+}
+
+// writeDOT writes the nodes and links in GraphViz DOT format
+func (s *SST) writeDOT(w io.Writer, nodes []*Node, links []*Link) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(s.config.Name))
+	fmt.Fprintln(bw, "\tnode [shape=box, style=\"rounded,filled\"];")
+
+	collections := make([]string, 0)
+	members := make(map[string][]*Node)
+	for _, node := range nodes {
+		kind := strings.TrimSuffix(node.Prefix, "/")
+		if _, seen := members[kind]; !seen {
+			collections = append(collections, kind)
+		}
+		members[kind] = append(members[kind], node)
+	}
+	for i, kind := range collections {
+		fmt.Fprintf(bw, "\tsubgraph %s {\n", dotQuote("cluster_"+kind))
+		fmt.Fprintf(bw, "\t\tlabel=%s;\n", dotQuote(kind))
+		fmt.Fprintf(bw, "\t\tnode [fillcolor=%s];\n", dotQuote(dotColors[i%len(dotColors)]))
+		for _, node := range members[kind] {
+			fmt.Fprintf(bw, "\t\t%s [label=%s];\n", dotQuote(node.Prefix+node.Key), dotQuote(node.Label()))
+		}
+		fmt.Fprintln(bw, "\t}")
+	}
+
+	for _, link := range links {
+		attrs := []string{"label=" + dotQuote(link.SID)}
+		if a := s.association(link.SID); a != nil {
+			typ := a.SemanticType.abs()
+			attrs = []string{"label=" + dotQuote(a.Fwd), "color=" + dotQuote(dotLinkColors[typ])}
+			if typ == Near {
+				attrs = append(attrs, "dir=none")
+			}
+		}
+		if MustLinkKeyNegated(link.Key) {
+			attrs = append(attrs, "style=dashed")
+		}
+		attrs = append(attrs, "penwidth="+strconv.FormatFloat(dotPenWidth(link.Weight), 'g', -1, 64))
+		fmt.Fprintf(bw, "\t%s -> %s [%s];\n", dotQuote(link.From), dotQuote(link.To), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotPenWidth returns the width of a link of the designated weight, bounded to [0.5, 8]
+func dotPenWidth(weight float64) float64 {
+	return math.Max(0.5, math.Min(8, weight))
+}
+
+// dotQuote quotes the string as a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,51 @@
+package sst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+	s, err := NewSST(&Config{
+		Backend:         NewMemoryBackend(),
+		Name:            "memory",
+		NodeCollections: []string{"Hub", "Node"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	emily := s.MustCreateNode("Node", "Emily", nil, 1)
+	paris := s.MustCreateNode("Hub", "Paris", nil, 1)
+	france := s.MustCreateNode("Hub", "France", nil, 1)
+	london := s.MustCreateNode("Hub", "London", nil, 1)
+	s.MustCreateLink(emily, "coactive", paris, nil, 1)
+	s.MustCreateLink(paris, "part_of", france, nil, 3)
+	s.MustBlockLink(london, "part_of", france, nil, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteDOT(&buf))
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph \"memory\" {\n"))
+	assert.Contains(t, dot, "\tsubgraph \"cluster_Hub\" {\n\t\tlabel=\"Hub\";\n\t\tnode [fillcolor=\"lightblue\"];\n\t\t\"Hub/France\" [label=\"France\"];\n")
+	assert.Contains(t, dot, "\tsubgraph \"cluster_Node\" {\n\t\tlabel=\"Node\";\n\t\tnode [fillcolor=\"lightyellow\"];\n\t\t\"Node/Emily\" [label=\"Emily\"];\n")
+	assert.Contains(t, dot, "\t\"Node/Emily\" -> \"Hub/Paris\" [label=\"occurred together with\", color=\"grey40\", dir=none, penwidth=1];\n")
+	assert.Contains(t, dot, "\t\"Hub/Paris\" -> \"Hub/France\" [label=\"is part of\", color=\"darkgreen\", penwidth=3];\n")
+	assert.Contains(t, dot, "\t\"Hub/London\" -> \"Hub/France\" [label=\"is part of\", color=\"darkgreen\", style=dashed, penwidth=1];\n")
+
+	buf.Reset()
+	assert.NoError(t, s.WriteNeighbourhoodDOT(&buf, paris, &TraversalOptions{SemanticTypes: []SemanticType{Contains}, Direction: Any, Depth: 1, IncludeNegated: true}))
+	dot = buf.String()
+	assert.Contains(t, dot, "\"Hub/Paris\" [label=\"Paris\"]")
+	assert.Contains(t, dot, "\"Hub/France\" [label=\"France\"]")
+	assert.NotContains(t, dot, "Emily")
+	assert.NotContains(t, dot, "London")
+
+	buf.Reset()
+	assert.NoError(t, s.WriteNeighbourhoodDOT(&buf, paris, nil))
+	assert.Contains(t, buf.String(), "\"Hub/Paris\" [label=\"Paris\"]")
+
+	assert.Equal(t, `"say \"hi\"\n"`, dotQuote("say \"hi\"\n"))
+}
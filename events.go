@@ -1,10 +1,152 @@
 package sst
 
-import "github.com/pkg/errors"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTimeline is the name of the timeline used by SST event operations
+	DefaultTimeline = "default"
+)
+
+// Timeline is a named sequence of events linked by "then" links. The head of
+// the timeline, its latest events, is stored in the Backend so that the timeline
+// can be resumed by another SST.
+//
+// A Timeline is safe for concurrent use. Events recorded concurrently on the same
+// Timeline are serialized, each set of events following the set recorded before
+// it. Goroutines recording independent sequences of events, such as the journeys
+// of different travellers, should each record on their own Timeline. A Timeline
+// is a cursor within one SST, SSTs sharing a Backend should not record on the
+// same Timeline concurrently.
+type Timeline struct {
+	key  string
+	name string
+	s    *SST
+
+	// mu guards head, it is held while recording events
+	mu   sync.Mutex
+	head []*Node
+}
+
+var (
+	missingEventTime = errors.New("sst: event has no observation time")
+)
+
+// eventTimeLayout formats observation times so that they sort lexicographically
+const eventTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// eventDocument records the observation of an event on a timeline
+type eventDocument struct {
+	Node     string `json:"node"`
+	Time     string `json:"time"`
+	Timeline string `json:"timeline,omitempty"`
+}
+
+// event decodes the recorded event
+func (d *eventDocument) event() (*TimelineEvent, error) {
+	at, err := time.Parse(eventTimeLayout, d.Time)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: invalid time of event: %v", d.Node)
+	}
+	return &TimelineEvent{ID: d.Node, Time: at}, nil
+}
+
+// formatEventTime formats the observation time of an event
+func formatEventTime(at time.Time) string {
+	return at.UTC().Format(eventTimeLayout)
+}
+
+// eventBefore returns true if event a orders before event b
+func eventBefore(a, b *TimelineEvent) bool {
+	if a.Time.Equal(b.Time) {
+		return a.ID < b.ID
+	}
+	return a.Time.Before(b.Time)
+}
+
+// EventDuration returns the time elapsed between the observation of two events
+func EventDuration(from, to *Node) (time.Duration, error) {
+	if from.Time == nil {
+		return 0, errors.Wrapf(missingEventTime, "sst: cannot measure duration from: %v", from.Key)
+	}
+	if to.Time == nil {
+		return 0, errors.Wrapf(missingEventTime, "sst: cannot measure duration to: %v", to.Key)
+	}
+	return to.Time.Sub(*from.Time), nil
+}
+
+// timelineDocument stores the _ids of the head events of a timeline
+type timelineDocument struct {
+	Key  string   `json:"_key"`
+	Head []string `json:"head"`
+}
+
+// Timeline returns the named timeline, resuming it from its stored head if it exists.
+func (s *SST) Timeline(name string) (*Timeline, error) {
+	return s.TimelineContext(context.Background(), name)
+}
+
+// TimelineContext returns the named timeline using the provided context.
+func (s *SST) TimelineContext(ctx context.Context, name string) (*Timeline, error) {
+	key := identifierKey(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.timelines[key]; t != nil {
+		return t, nil
+	}
+	ids, err := s.backend.ReadTimeline(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	t := &Timeline{
+		head: []*Node{startEvent},
+		key:  key,
+		name: name,
+		s:    s,
+	}
+	if len(ids) > 0 {
+		t.head = make([]*Node, 0, len(ids))
+		for _, id := range ids {
+			node, err := s.backend.ReadNode(ctx, id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "sst: failed to read event: %v of timeline: %v", id, name)
+			}
+			node.Prefix, _ = splitNodeID(id)
+			node.Prefix += "/"
+			t.head = append(t.head, node)
+		}
+	}
+	s.timelines[key] = t
+	return t, nil
+}
+
+// MustTimeline returns the named timeline, but panics on error.
+func (s *SST) MustTimeline(name string) *Timeline {
+	t, err := s.Timeline(name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Name returns the name of the timeline.
+func (t *Timeline) Name() string {
+	return t.name
+}
 
 // NextEvent creates a singular next event.
-func (s *SST) NextEvent(kind, key string, data map[string]interface{}) (*Node, error) {
-	nodes, err := s.NextEvents([]string{kind}, []string{key}, []map[string]interface{}{data})
+func (t *Timeline) NextEvent(kind, key string, data map[string]interface{}) (*Node, error) {
+	return t.NextEventContext(context.Background(), kind, key, data)
+}
+
+// NextEventContext creates a singular next event using the provided context.
+func (t *Timeline) NextEventContext(ctx context.Context, kind, key string, data map[string]interface{}) (*Node, error) {
+	nodes, err := t.NextEventsContext(ctx, []string{kind}, []string{key}, []map[string]interface{}{data})
 	if err != nil {
 		return nil, err
 	}
@@ -12,50 +154,284 @@ func (s *SST) NextEvent(kind, key string, data map[string]interface{}) (*Node, e
 }
 
 // MustNextEvent creates a singular next event, but panics on error.
-func (s *SST) MustNextEvent(kind, key string, data map[string]interface{}) *Node {
-	node, err := s.NextEvent(kind, key, data)
+func (t *Timeline) MustNextEvent(kind, key string, data map[string]interface{}) *Node {
+	node, err := t.NextEvent(kind, key, data)
 	if err != nil {
 		panic(err)
 	}
 	return node
 }
 
-// NextEvents creates a set of next parallel events.
-func (s *SST) NextEvents(kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
-	newset := make([]*Node, 0)
-	var evnt *Node
-	var err error
+// NextEventAt creates a singular next event observed at the designated time.
+func (t *Timeline) NextEventAt(at time.Time, kind, key string, data map[string]interface{}) (*Node, error) {
+	return t.NextEventAtContext(context.Background(), at, kind, key, data)
+}
+
+// NextEventAtContext creates a singular next event observed at the designated time using the provided context.
+func (t *Timeline) NextEventAtContext(ctx context.Context, at time.Time, kind, key string, data map[string]interface{}) (*Node, error) {
+	nodes, err := t.NextEventsAtContext(ctx, at, []string{kind}, []string{key}, []map[string]interface{}{data})
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// MustNextEventAt creates a singular next event observed at the designated time, but panics on error.
+func (t *Timeline) MustNextEventAt(at time.Time, kind, key string, data map[string]interface{}) *Node {
+	node, err := t.NextEventAt(at, kind, key, data)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// NextEvents creates a set of next parallel events observed now.
+func (t *Timeline) NextEvents(kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return t.NextEventsContext(context.Background(), kind, keys, data)
+}
+
+// NextEventsContext creates a set of next parallel events observed now using the provided context.
+func (t *Timeline) NextEventsContext(ctx context.Context, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return t.NextEventsAtContext(ctx, time.Now(), kind, keys, data)
+}
+
+// MustNextEvents creates a set of next parallel events observed now, but panics on error.
+func (t *Timeline) MustNextEvents(kind, keys []string, data []map[string]interface{}) []*Node {
+	nodes, err := t.NextEvents(kind, keys, data)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+// NextEventsAt creates a set of next parallel events observed at the designated time.
+// Event nodes and the "then" links leading to them are stamped with the time.
+func (t *Timeline) NextEventsAt(at time.Time, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return t.NextEventsAtContext(context.Background(), at, kind, keys, data)
+}
+
+// NextEventsAtContext creates a set of next parallel events observed at the designated time using the provided context.
+func (t *Timeline) NextEventsAtContext(ctx context.Context, at time.Time, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	newset := newEvents(at, kind, keys, data)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.s.update(ctx, func(tx BackendTx) error {
+		return t.record(ctx, tx, t.head, newset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.head = newset
+
+	return newset, nil
+}
+
+// newEvents creates the event nodes observed at the designated time
+func newEvents(at time.Time, kind, keys []string, data []map[string]interface{}) []*Node {
+	at = at.UTC()
+	newset := make([]*Node, 0, len(keys))
 	for i := range keys {
-		evnt, err = s.CreateNode(kind[i], keys[i], data[i], 1.0)
+		newset = append(newset, &Node{
+			Data:   data[i],
+			Key:    ToDocumentKey(keys[i]),
+			Name:   keys[i],
+			Prefix: kind[i] + "/",
+			Time:   &at,
+			Weight: 1.0,
+		})
+	}
+	return newset
+}
+
+// record writes the events following the head events of the timeline within
+// the transaction, linking each head event to each event, and advances the
+// stored head of the timeline to the events.
+func (t *Timeline) record(ctx context.Context, tx BackendTx, head, newset []*Node) error {
+	for _, evnt := range newset {
+		err := t.s.validateNode(ctx, evnt)
+		if err != nil {
+			return err
+		}
+		err = tx.UpsertNode(ctx, evnt)
 		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to create event: %v", keys[i])
+			return errors.Wrapf(err, "sst: failed to create event: %v", evnt.Key)
 		}
-		if s.prevEvents[0].Key != startEvent.Key {
+		if head[0].Key != startEvent.Key {
 			// Link all the previous events in the slice
-			for j := range s.prevEvents {
-				_, err = s.CreateLink(s.prevEvents[j], "then", evnt, nil, 1.0)
+			for j := range head {
+				association, link, err := t.s.newLink(MustNodeID(head[j]), "then", MustNodeID(evnt), nil, 1.0, false)
+				if err == nil {
+					link.Time = evnt.Time
+					_, err = tx.UpsertLink(ctx, association.SemanticType, link, AddLinkOp)
+				}
 				if err != nil {
-					return nil, errors.Wrapf(err, "sst: failed to link created event: %v with %v", evnt.Key, s.prevEvents[j].Key)
+					return errors.Wrapf(err, "sst: failed to link created event: %v with %v", evnt.Key, head[j].Key)
 				}
 			}
 		}
-		newset = append(newset, evnt)
 	}
-	s.prevEvents = newset
-
-	return newset, nil
+	ids := make([]string, 0, len(newset))
+	events := make([]*TimelineEvent, 0, len(newset))
+	for _, evnt := range newset {
+		id := MustNodeID(evnt)
+		ids = append(ids, id)
+		events = append(events, &TimelineEvent{ID: id, Time: *evnt.Time})
+	}
+	err := tx.AppendEvents(ctx, t.key, events)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to record events of timeline: %v", t.name)
+	}
+	err = tx.WriteTimeline(ctx, t.key, ids)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to advance timeline: %v", t.name)
+	}
+	return nil
 }
 
-// MustNextEvents creates a set of next parallel events, but panics on error.
-func (s *SST) MustNextEvents(kind, keys []string, data []map[string]interface{}) []*Node {
-	nodes, err := s.NextEvents(kind, keys, data)
+// MustNextEventsAt creates a set of next parallel events observed at the designated time, but panics on error.
+func (t *Timeline) MustNextEventsAt(at time.Time, kind, keys []string, data []map[string]interface{}) []*Node {
+	nodes, err := t.NextEventsAt(at, kind, keys, data)
 	if err != nil {
 		panic(err)
 	}
 	return nodes
 }
 
+// Events returns the events observed from the from time, inclusive, to the to
+// time, exclusive, ordered by observation time.
+func (t *Timeline) Events(from, to time.Time) ([]*Node, error) {
+	return t.EventsContext(context.Background(), from, to)
+}
+
+// EventsContext returns the events observed within the designated time range using the provided context.
+func (t *Timeline) EventsContext(ctx context.Context, from, to time.Time) ([]*Node, error) {
+	events, err := t.s.backend.ReadEvents(ctx, t.key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return t.readEvents(ctx, events)
+}
+
+// ActiveEvents returns the latest events observed at or before the designated
+// time, these are the events the timeline was at at that time.
+func (t *Timeline) ActiveEvents(at time.Time) ([]*Node, error) {
+	return t.ActiveEventsContext(context.Background(), at)
+}
+
+// ActiveEventsContext returns the events active at the designated time using the provided context.
+func (t *Timeline) ActiveEventsContext(ctx context.Context, at time.Time) ([]*Node, error) {
+	events, err := t.s.backend.ReadActiveEvents(ctx, t.key, at)
+	if err != nil {
+		return nil, err
+	}
+	return t.readEvents(ctx, events)
+}
+
+// readEvents reads the nodes of recorded events stamped with their observation time
+func (t *Timeline) readEvents(ctx context.Context, events []*TimelineEvent) ([]*Node, error) {
+	nodes := make([]*Node, 0, len(events))
+	for _, e := range events {
+		node, err := t.s.backend.ReadNode(ctx, e.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to read event: %v of timeline: %v", e.ID, t.name)
+		}
+		node.Prefix, _ = splitNodeID(e.ID)
+		node.Prefix += "/"
+		at := e.Time
+		node.Time = &at
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
 // PreviousEvents returns the previous events
+func (t *Timeline) PreviousEvents() []*Node {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Node(nil), t.head...)
+}
+
+// NextEvent creates a singular next event on the DefaultTimeline.
+func (s *SST) NextEvent(kind, key string, data map[string]interface{}) (*Node, error) {
+	return s.events.NextEvent(kind, key, data)
+}
+
+// NextEventContext creates a singular next event on the DefaultTimeline using the provided context.
+func (s *SST) NextEventContext(ctx context.Context, kind, key string, data map[string]interface{}) (*Node, error) {
+	return s.events.NextEventContext(ctx, kind, key, data)
+}
+
+// MustNextEvent creates a singular next event on the DefaultTimeline, but panics on error.
+func (s *SST) MustNextEvent(kind, key string, data map[string]interface{}) *Node {
+	return s.events.MustNextEvent(kind, key, data)
+}
+
+// NextEvents creates a set of next parallel events on the DefaultTimeline.
+func (s *SST) NextEvents(kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return s.events.NextEvents(kind, keys, data)
+}
+
+// NextEventsContext creates a set of next parallel events on the DefaultTimeline using the provided context.
+func (s *SST) NextEventsContext(ctx context.Context, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return s.events.NextEventsContext(ctx, kind, keys, data)
+}
+
+// MustNextEvents creates a set of next parallel events on the DefaultTimeline, but panics on error.
+func (s *SST) MustNextEvents(kind, keys []string, data []map[string]interface{}) []*Node {
+	return s.events.MustNextEvents(kind, keys, data)
+}
+
+// NextEventAt creates a singular next event observed at the designated time on the DefaultTimeline.
+func (s *SST) NextEventAt(at time.Time, kind, key string, data map[string]interface{}) (*Node, error) {
+	return s.events.NextEventAt(at, kind, key, data)
+}
+
+// NextEventAtContext creates a singular next event observed at the designated time on the DefaultTimeline using the provided context.
+func (s *SST) NextEventAtContext(ctx context.Context, at time.Time, kind, key string, data map[string]interface{}) (*Node, error) {
+	return s.events.NextEventAtContext(ctx, at, kind, key, data)
+}
+
+// MustNextEventAt creates a singular next event observed at the designated time on the DefaultTimeline, but panics on error.
+func (s *SST) MustNextEventAt(at time.Time, kind, key string, data map[string]interface{}) *Node {
+	return s.events.MustNextEventAt(at, kind, key, data)
+}
+
+// NextEventsAt creates a set of next parallel events observed at the designated time on the DefaultTimeline.
+func (s *SST) NextEventsAt(at time.Time, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return s.events.NextEventsAt(at, kind, keys, data)
+}
+
+// NextEventsAtContext creates a set of next parallel events observed at the designated time on the DefaultTimeline using the provided context.
+func (s *SST) NextEventsAtContext(ctx context.Context, at time.Time, kind, keys []string, data []map[string]interface{}) ([]*Node, error) {
+	return s.events.NextEventsAtContext(ctx, at, kind, keys, data)
+}
+
+// MustNextEventsAt creates a set of next parallel events observed at the designated time on the DefaultTimeline, but panics on error.
+func (s *SST) MustNextEventsAt(at time.Time, kind, keys []string, data []map[string]interface{}) []*Node {
+	return s.events.MustNextEventsAt(at, kind, keys, data)
+}
+
+// PreviousEvents returns the previous events of the DefaultTimeline
 func (s *SST) PreviousEvents() []*Node {
-	return s.prevEvents
+	return s.events.PreviousEvents()
+}
+
+// Events returns the events of the DefaultTimeline observed within the designated time range.
+func (s *SST) Events(from, to time.Time) ([]*Node, error) {
+	return s.events.Events(from, to)
+}
+
+// EventsContext returns the events of the DefaultTimeline observed within the designated time range using the provided context.
+func (s *SST) EventsContext(ctx context.Context, from, to time.Time) ([]*Node, error) {
+	return s.events.EventsContext(ctx, from, to)
+}
+
+// ActiveEvents returns the events of the DefaultTimeline active at the designated time.
+func (s *SST) ActiveEvents(at time.Time) ([]*Node, error) {
+	return s.events.ActiveEvents(at)
+}
+
+// ActiveEventsContext returns the events of the DefaultTimeline active at the designated time using the provided context.
+func (s *SST) ActiveEventsContext(ctx context.Context, at time.Time) ([]*Node, error) {
+	return s.events.ActiveEventsContext(ctx, at)
 }
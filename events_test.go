@@ -0,0 +1,102 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimelines(t *testing.T) {
+	s := memorySST(t)
+	assert.Equal(t, []*Node{startEvent}, s.PreviousEvents())
+
+	journey := s.MustTimeline("journey-markburgess")
+	other := s.MustTimeline("journey-emily")
+	assert.Same(t, journey, s.MustTimeline("journey-markburgess"))
+
+	e1 := journey.MustNextEvent("Node", "London", nil)
+	e2 := journey.MustNextEvent("Node", "New York", nil)
+	e3 := other.MustNextEvent("Node", "Paris", nil)
+	assert.Equal(t, []*Node{e2}, journey.PreviousEvents())
+	assert.Equal(t, []*Node{e3}, other.PreviousEvents())
+	assert.Equal(t, []*Node{startEvent}, s.PreviousEvents())
+
+	_, err := s.backend.ReadLink(context.TODO(), Follows, linkKey(MustNodeID(e1), "then", MustNodeID(e2), false))
+	assert.NoError(t, err)
+	_, err = s.backend.ReadLink(context.TODO(), Follows, linkKey(MustNodeID(e2), "then", MustNodeID(e3), false))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestTimelineResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sst.db")
+	s := boltSST(t, path)
+	s.MustNextEvents([]string{"Node", "Node"}, []string{"a", "b"}, []map[string]interface{}{nil, nil})
+	s.MustTimeline("journey").MustNextEvent("Node", "c", nil)
+	assert.NoError(t, s.Close())
+
+	s = boltSST(t, path)
+	defer s.Close()
+	assert.Len(t, s.PreviousEvents(), 2)
+	assert.Equal(t, "a", s.PreviousEvents()[0].Key)
+	assert.Equal(t, "Node/", s.PreviousEvents()[0].Prefix)
+	d := s.MustNextEvent("Node", "d", nil)
+	for _, prev := range []string{"Node/a", "Node/b"} {
+		_, err := s.backend.ReadLink(context.TODO(), Follows, linkKey(prev, "then", MustNodeID(d), false))
+		assert.NoError(t, err)
+	}
+
+	journey := s.MustTimeline("journey")
+	assert.Equal(t, "c", journey.PreviousEvents()[0].Key)
+	e := journey.MustNextEvent("Node", "e", nil)
+	_, err := s.backend.ReadLink(context.TODO(), Follows, linkKey("Node/c", "then", MustNodeID(e), false))
+	assert.NoError(t, err)
+}
+
+func testEventTimes(t *testing.T, s *SST) {
+	t0 := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)
+	journey := s.MustTimeline("journey")
+	london := journey.MustNextEventAt(t0, "Node", "London", nil)
+	journey.MustNextEventsAt(t0.Add(2*time.Hour), []string{"Node", "Node"}, []string{"Paris", "Brussels"}, []map[string]interface{}{nil, nil})
+	berlin := journey.MustNextEventAt(t0.Add(5*time.Hour), "Node", "Berlin", nil)
+
+	link, err := s.backend.ReadLink(context.TODO(), Follows, linkKey("Node/Paris", "then", "Node/Berlin", false))
+	assert.NoError(t, err)
+	assert.True(t, t0.Add(5*time.Hour).Equal(*link.Time))
+
+	events, err := journey.Events(t0, t0.Add(5*time.Hour))
+	assert.NoError(t, err)
+	keys := make([]string, 0)
+	for _, e := range events {
+		keys = append(keys, e.Key)
+	}
+	assert.Equal(t, []string{"London", "Brussels", "Paris"}, keys)
+	assert.True(t, t0.Add(2*time.Hour).Equal(*events[1].Time))
+
+	active, err := journey.ActiveEvents(t0.Add(3 * time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, active, 2)
+	assert.Equal(t, "Brussels", active[0].Key)
+	active, err = journey.ActiveEvents(t0.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+	active, err = journey.ActiveEvents(t0.Add(5 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Berlin"}, []string{active[0].Key})
+
+	d, err := EventDuration(london, berlin)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Hour, d)
+	_, err = EventDuration(london, &Node{Key: "timeless"})
+	assert.Equal(t, missingEventTime, errors.Cause(err))
+}
+
+func TestEventTimes(t *testing.T) {
+	testEventTimes(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testEventTimes(t, s)
+}
@@ -82,32 +82,31 @@ func main() {
 	PersonLocation(s, "Captain Evil", "Washington DC")
 }
 
+// PersonData is the data of a Person node
+type PersonData struct {
+	Description string `json:"description,omitempty"`
+	Number      int    `json:"number,omitempty"`
+}
+
+// Description is the data of Country, Location and Event nodes
+type Description struct {
+	Description string `json:"description,omitempty"`
+}
+
 func CreatePerson(s *sst.SST, short, description string, number int, weight float64) *sst.Node {
-	return s.MustCreateNode(string(Person), short, map[string]interface{}{"description": description, "number": number}, weight)
+	return sst.MustCreateTypedNode(s, string(Person), short, &PersonData{Description: description, Number: number}, weight).Node
 }
 
 func CreateCountry(s *sst.SST, short, description string) *sst.Node {
-	var data map[string]interface{}
-	if description != "" {
-		data = map[string]interface{}{"description": description}
-	}
-	return s.MustCreateNode(string(Country), short, data, 0)
+	return sst.MustCreateTypedNode(s, string(Country), short, &Description{description}, 0).Node
 }
 
 func CreateLocation(s *sst.SST, short, description string) *sst.Node {
-	var data map[string]interface{}
-	if description != "" {
-		data = map[string]interface{}{"description": description}
-	}
-	return s.MustCreateNode(string(Location), short, data, 0)
+	return sst.MustCreateTypedNode(s, string(Location), short, &Description{description}, 0).Node
 }
 
 func CreateEvent(s *sst.SST, short, description string) *sst.Node {
-	var data map[string]interface{}
-	if description != "" {
-		data = map[string]interface{}{"description": description}
-	}
-	return s.MustCreateNode(string(Event), short, data, 0)
+	return sst.MustCreateTypedNode(s, string(Event), short, &Description{description}, 0).Node
 }
 
 func LocationCountry(s *sst.SST, location, country string) {
@@ -1,15 +1,17 @@
 module github.com/tristanls/sst
 
-go 1.17
+go 1.18
 
 require (
 	github.com/arangodb/go-driver v1.2.1
 	github.com/pkg/errors v0.9.1
+	go.etcd.io/bbolt v1.3.6
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20200923182605-d9f96fdee20d // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
 
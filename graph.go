@@ -0,0 +1,245 @@
+package sst
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	unknownNodeCollection = errors.New("sst: unknown node collection")
+)
+
+// Graph is the portable representation of a whole Semantic Spacetime: its
+// associations, node collections, nodes and links. Timelines are not part of a
+// Graph, although their events and the links between them are.
+//
+// The JSON format written by WriteJSON is the JSON encoding of a Graph:
+//
+//	{
+//	  "associations": [{"_key": "part_of", "stype": 2, "fwd": "is part of", "bwd": "incorporates", "nfwd": "is not part of", "nbwd": "doesn't incorporate"}],
+//	  "collections": ["Node"],
+//	  "nodes": [{"collection": "Node", "key": "Paris", "name": "Paris", "data": {"country": "France"}, "weight": 1}],
+//	  "links": [{"key": "+Node%2FParispart_ofNode%2FFrance", "from": "Node/Paris", "to": "Node/France", "association": "part_of", "negated": false, "weight": 1}]
+//	}
+type Graph struct {
+	// Associations are ordered by key
+	Associations []*Association `json:"associations"`
+	// Collections are the names of the node collections
+	Collections []string `json:"collections"`
+	// Nodes are ordered by collection and key
+	Nodes []*GraphNode `json:"nodes"`
+	// Links are ordered by SemanticType and key
+	Links []*GraphLink `json:"links"`
+}
+
+// GraphNode is the portable representation of a Node
+type GraphNode struct {
+	Collection string                 `json:"collection"`
+	Key        string                 `json:"key"`
+	Name       string                 `json:"name,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Weight     float64                `json:"weight"`
+	Time       *time.Time             `json:"time,omitempty"`
+}
+
+// Label returns the name of the node, decoded from its key if it has no name
+func (n *GraphNode) Label() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return FromDocumentKey(n.Key)
+}
+
+// GraphLink is the portable representation of a Link
+type GraphLink struct {
+	// Key is the _key of the link, derived from the other fields if empty
+	Key         string                 `json:"key,omitempty"`
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Association string                 `json:"association"`
+	Negated     bool                   `json:"negated"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Weight      float64                `json:"weight"`
+	Time        *time.Time             `json:"time,omitempty"`
+}
+
+// ExportGraph reads the whole spacetime as a Graph
+func (s *SST) ExportGraph() (*Graph, error) {
+	return s.ExportGraphContext(context.Background())
+}
+
+// ExportGraphContext invokes ExportGraph using the provided context
+func (s *SST) ExportGraphContext(ctx context.Context) (*Graph, error) {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, links, err := s.readGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{
+		Associations: s.Associations(),
+		Collections:  collections,
+		Nodes:        make([]*GraphNode, 0, len(nodes)),
+		Links:        make([]*GraphLink, 0, len(links)),
+	}
+	for _, node := range nodes {
+		g.Nodes = append(g.Nodes, &GraphNode{Collection: strings.TrimSuffix(node.Prefix, "/"), Key: node.Key, Name: node.Name, Data: node.Data, Weight: node.Weight, Time: node.Time})
+	}
+	for _, link := range links {
+		g.Links = append(g.Links, &GraphLink{
+			Key:         link.Key,
+			From:        link.From,
+			To:          link.To,
+			Association: link.SID,
+			Negated:     MustLinkKeyNegated(link.Key),
+			Data:        link.Data,
+			Weight:      link.Weight,
+			Time:        link.Time,
+		})
+	}
+	return g, nil
+}
+
+// ImportGraph stores the associations, nodes and links of the graph. The SST must
+// be configured with the node collections of the graph. Nodes and links are
+// stored with the same _key as in the graph, so that importing the export of a
+// spacetime into an empty spacetime reproduces it. Existing nodes and links are
+// updated the way CreateNode and CreateLink update them. Returns a BatchError
+// reporting the nodes, then the links, that failed.
+func (s *SST) ImportGraph(g *Graph) error {
+	return s.ImportGraphContext(context.Background(), g)
+}
+
+// ImportGraphContext invokes ImportGraph using the provided context
+func (s *SST) ImportGraphContext(ctx context.Context, g *Graph) error {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, name := range collections {
+		known[name] = true
+	}
+	for _, name := range g.Collections {
+		if !known[name] {
+			return errors.Wrapf(unknownNodeCollection, "sst: failed to import graph: %v", name)
+		}
+	}
+	for _, a := range g.Associations {
+		copied := *a
+		err := s.CreateAssociationContext(ctx, &copied)
+		if err != nil {
+			return err
+		}
+	}
+
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, &Node{Prefix: n.Collection + "/", Key: n.Key, Name: n.Name, Data: n.Data, Weight: n.Weight, Time: n.Time})
+	}
+	errs := make(BatchError, len(g.Nodes)+len(g.Links))
+	if err := s.backend.UpsertNodes(ctx, nodes); err != nil {
+		batch, ok := err.(BatchError)
+		if !ok {
+			return err
+		}
+		copy(errs, batch)
+	}
+
+	types := make([]SemanticType, 0, len(g.Links))
+	links := make([]*Link, 0, len(g.Links))
+	indexes := make([]int, 0, len(g.Links))
+	for i, l := range g.Links {
+		a := s.association(l.Association)
+		if a == nil {
+			errs[len(nodes)+i] = errors.Wrapf(unknownAssociation, "sst: invalid link type: %v", l.Association)
+			continue
+		}
+		link := &Link{Key: l.Key, From: l.From, To: l.To, SID: a.Key, Data: l.Data, Weight: l.Weight, Time: l.Time}
+		if link.Key == "" {
+			link.Key = linkKey(link.From, link.SID, link.To, l.Negated)
+		}
+		types = append(types, a.SemanticType)
+		links = append(links, link)
+		indexes = append(indexes, len(nodes)+i)
+	}
+	if len(links) > 0 {
+		_, err := s.backend.UpsertLinks(ctx, types, links, AddLinkOp)
+		if err != nil {
+			batch, ok := err.(BatchError)
+			if !ok {
+				return err
+			}
+			for i, e := range batch {
+				errs[indexes[i]] = e
+			}
+		}
+	}
+	return errs.orNil()
+}
+
+// WriteJSON writes the whole spacetime in the JSON format documented by Graph
+func (s *SST) WriteJSON(w io.Writer) error {
+	return s.WriteJSONContext(context.Background(), w)
+}
+
+// WriteJSONContext invokes WriteJSON using the provided context
+func (s *SST) WriteJSONContext(ctx context.Context, w io.Writer) error {
+	g, err := s.ExportGraphContext(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+// ImportJSON imports the graph read in the JSON format documented by Graph, see ImportGraph
+func (s *SST) ImportJSON(r io.Reader) error {
+	return s.ImportJSONContext(context.Background(), r)
+}
+
+// ImportJSONContext invokes ImportJSON using the provided context
+func (s *SST) ImportJSONContext(ctx context.Context, r io.Reader) error {
+	var g Graph
+	err := json.NewDecoder(r).Decode(&g)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to decode JSON graph")
+	}
+	return s.ImportGraphContext(ctx, &g)
+}
+
+// readGraph reads all nodes, ordered by collection and _key, and all links
+func (s *SST) readGraph(ctx context.Context) ([]*Node, []*Link, error) {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]*Node, 0)
+	for _, name := range collections {
+		read, err := s.backend.ReadNodes(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, node := range read {
+			node.Prefix = name + "/"
+		}
+		nodes = append(nodes, read...)
+	}
+	links := make([]*Link, 0)
+	for _, typ := range linkTypes(nil) {
+		read, err := s.backend.ReadLinks(ctx, typ)
+		if err != nil {
+			return nil, nil, err
+		}
+		links = append(links, read...)
+	}
+	return nodes, links, nil
+}
@@ -0,0 +1,80 @@
+package sst
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func graphSST(t *testing.T, collections ...string) *SST {
+	s, err := NewSST(&Config{
+		Backend:         NewMemoryBackend(),
+		Name:            "memory",
+		NodeCollections: collections,
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	return s
+}
+
+func TestGraphRoundTrip(t *testing.T) {
+	s := graphSST(t, "Hub", "Node")
+	s.MustCreateAssociation(&Association{Key: "capital_of", SemanticType: Contains, Fwd: "is capital of", Bwd: "has capital", Nfwd: "is not capital of", Nbwd: "does not have capital"})
+	paris := s.MustCreateNode("Hub", "Paris", map[string]interface{}{"population": 2.1, "tags": []interface{}{"city"}}, 2)
+	france := s.MustCreateNode("Hub", "France", nil, 1)
+	london := s.MustCreateNode("Node", "London", nil, 0.5)
+	s.MustCreateNode("Node", "New York", nil, 1)
+	s.MustCreateLink(paris, "capital_of", france, map[string]interface{}{"since": "987"}, 3)
+	s.MustBlockLink(london, "capital_of", france, nil, 1)
+	s.MustTimeline("trip").MustNextEventAt(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), "Node", "arrive", nil)
+
+	want, err := s.ExportGraph()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hub", "Node"}, want.Collections)
+	names := make(map[string]string)
+	for _, n := range want.Nodes {
+		names[n.Key] = n.Name
+	}
+	assert.Equal(t, "New York", names["New%20York"])
+
+	for name, codec := range map[string]struct {
+		write func(s *SST, buf *bytes.Buffer) error
+		read  func(s *SST, buf *bytes.Buffer) error
+	}{
+		"JSON": {
+			write: func(s *SST, buf *bytes.Buffer) error { return s.WriteJSON(buf) },
+			read:  func(s *SST, buf *bytes.Buffer) error { return s.ImportJSON(buf) },
+		},
+		"GraphML": {
+			write: func(s *SST, buf *bytes.Buffer) error { return s.WriteGraphML(buf) },
+			read:  func(s *SST, buf *bytes.Buffer) error { return s.ImportGraphML(buf) },
+		},
+	} {
+		var buf bytes.Buffer
+		assert.NoError(t, codec.write(s, &buf), name)
+		imported := graphSST(t, "Hub", "Node")
+		assert.NoError(t, codec.read(imported, &buf), name)
+		got, err := imported.ExportGraph()
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteJSON(&buf))
+	err = graphSST(t, "Node").ImportJSON(&buf)
+	assert.Equal(t, unknownNodeCollection, errors.Cause(err))
+
+	err = graphSST(t, "Node").ImportGraph(&Graph{
+		Nodes: []*GraphNode{{Collection: "Node", Key: "Paris"}, {Collection: "Node", Key: "France"}},
+		Links: []*GraphLink{{From: "Node/Paris", To: "Node/France", Association: "part_of"}, {From: "Node/Paris", To: "Node/France", Association: "unknown"}},
+	})
+	batch, ok := err.(BatchError)
+	assert.True(t, ok)
+	assert.Len(t, batch, 4)
+	assert.NoError(t, batch[2])
+	assert.True(t, IsUnknownAssociation(batch[3]))
+}
@@ -0,0 +1,278 @@
+package sst
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// graphMLKeys declare the GraphML attributes of graphs, nodes and edges. The
+// label attributes are read by tools such as Gephi and ignored on import.
+var graphMLKeys = []graphMLKey{
+	{ID: "associations", For: "graph", Name: "associations", Type: "string"},
+	{ID: "collections", For: "graph", Name: "collections", Type: "string"},
+	{ID: "n_label", For: "node", Name: "label", Type: "string"},
+	{ID: "n_collection", For: "node", Name: "collection", Type: "string"},
+	{ID: "n_key", For: "node", Name: "key", Type: "string"},
+	{ID: "n_name", For: "node", Name: "name", Type: "string"},
+	{ID: "n_data", For: "node", Name: "data", Type: "string"},
+	{ID: "n_weight", For: "node", Name: "weight", Type: "double"},
+	{ID: "n_time", For: "node", Name: "time", Type: "string"},
+	{ID: "e_label", For: "edge", Name: "label", Type: "string"},
+	{ID: "e_key", For: "edge", Name: "key", Type: "string"},
+	{ID: "e_association", For: "edge", Name: "association", Type: "string"},
+	{ID: "e_negated", For: "edge", Name: "negated", Type: "boolean"},
+	{ID: "e_data", For: "edge", Name: "data", Type: "string"},
+	{ID: "e_weight", For: "edge", Name: "weight", Type: "double"},
+	{ID: "e_time", For: "edge", Name: "time", Type: "string"},
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr,omitempty"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the whole spacetime in GraphML format. The association
+// table and node collections are JSON encoded graph attributes. Node and link
+// data are JSON encoded node and edge attributes.
+func (s *SST) WriteGraphML(w io.Writer) error {
+	return s.WriteGraphMLContext(context.Background(), w)
+}
+
+// WriteGraphMLContext invokes WriteGraphML using the provided context
+func (s *SST) WriteGraphMLContext(ctx context.Context, w io.Writer) error {
+	g, err := s.ExportGraphContext(ctx)
+	if err != nil {
+		return err
+	}
+	associations, err := json.Marshal(g.Associations)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to encode associations")
+	}
+	collections, err := json.Marshal(g.Collections)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to encode node collections")
+	}
+	doc := &graphML{
+		XMLNS: graphMLNamespace,
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{
+			ID:          s.config.Name,
+			EdgeDefault: "directed",
+			Data: []graphMLData{
+				{Key: "associations", Value: string(associations)},
+				{Key: "collections", Value: string(collections)},
+			},
+		},
+	}
+	for _, n := range g.Nodes {
+		data := []graphMLData{
+			{Key: "n_label", Value: n.Label()},
+			{Key: "n_collection", Value: n.Collection},
+			{Key: "n_key", Value: n.Key},
+			{Key: "n_weight", Value: strconv.FormatFloat(n.Weight, 'g', -1, 64)},
+		}
+		if n.Name != "" {
+			data = append(data, graphMLData{Key: "n_name", Value: n.Name})
+		}
+		data, err = appendGraphMLExtras(data, "n_", n.Data, n.Time)
+		if err != nil {
+			return err
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.Collection + "/" + n.Key, Data: data})
+	}
+	for _, l := range g.Links {
+		label := l.Association
+		if a := s.association(l.Association); a != nil {
+			label = a.Phrase(true, l.Negated)
+		}
+		data := []graphMLData{
+			{Key: "e_label", Value: label},
+			{Key: "e_key", Value: l.Key},
+			{Key: "e_association", Value: l.Association},
+			{Key: "e_negated", Value: strconv.FormatBool(l.Negated)},
+			{Key: "e_weight", Value: strconv.FormatFloat(l.Weight, 'g', -1, 64)},
+		}
+		data, err = appendGraphMLExtras(data, "e_", l.Data, l.Time)
+		if err != nil {
+			return err
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{ID: l.Key, Source: l.From, Target: l.To, Data: data})
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to encode GraphML graph")
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// ImportGraphML imports the graph read in the GraphML format written by
+// WriteGraphML, see ImportGraph. Attributes are identified by their attr.name.
+func (s *SST) ImportGraphML(r io.Reader) error {
+	return s.ImportGraphMLContext(context.Background(), r)
+}
+
+// ImportGraphMLContext invokes ImportGraphML using the provided context
+func (s *SST) ImportGraphMLContext(ctx context.Context, r io.Reader) error {
+	var doc graphML
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to decode GraphML graph")
+	}
+	names := make(map[string]string)
+	for _, k := range doc.Keys {
+		names[k.ID] = k.Name
+	}
+
+	g := &Graph{}
+	attrs := graphMLAttributes(names, doc.Graph.Data)
+	if v, ok := attrs["associations"]; ok {
+		err := json.Unmarshal([]byte(v), &g.Associations)
+		if err != nil {
+			return errors.Wrap(err, "sst: failed to decode associations")
+		}
+	}
+	if v, ok := attrs["collections"]; ok {
+		err := json.Unmarshal([]byte(v), &g.Collections)
+		if err != nil {
+			return errors.Wrap(err, "sst: failed to decode node collections")
+		}
+	}
+	for _, n := range doc.Graph.Nodes {
+		attrs := graphMLAttributes(names, n.Data)
+		kind, key := splitNodeID(n.ID)
+		node := &GraphNode{Collection: kind, Key: key}
+		if v, ok := attrs["collection"]; ok {
+			node.Collection = v
+		}
+		if v, ok := attrs["key"]; ok {
+			node.Key = v
+		}
+		node.Name = attrs["name"]
+		node.Data, node.Weight, node.Time, err = parseGraphMLExtras(attrs)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to decode node: %v", n.ID)
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	for _, e := range doc.Graph.Edges {
+		attrs := graphMLAttributes(names, e.Data)
+		link := &GraphLink{Key: attrs["key"], From: e.Source, To: e.Target, Association: attrs["association"]}
+		if v, ok := attrs["negated"]; ok {
+			link.Negated, err = strconv.ParseBool(v)
+			if err != nil {
+				return errors.Wrapf(err, "sst: failed to decode link: %v", e.ID)
+			}
+		}
+		link.Data, link.Weight, link.Time, err = parseGraphMLExtras(attrs)
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to decode link: %v", e.ID)
+		}
+		g.Links = append(g.Links, link)
+	}
+	return s.ImportGraphContext(ctx, g)
+}
+
+// appendGraphMLExtras appends the data and time attributes, if set, using the key prefix
+func appendGraphMLExtras(attrs []graphMLData, prefix string, data map[string]interface{}, t *time.Time) ([]graphMLData, error) {
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "sst: failed to encode data")
+		}
+		attrs = append(attrs, graphMLData{Key: prefix + "data", Value: string(encoded)})
+	}
+	if t != nil {
+		attrs = append(attrs, graphMLData{Key: prefix + "time", Value: t.Format(time.RFC3339Nano)})
+	}
+	return attrs, nil
+}
+
+// parseGraphMLExtras parses the data, weight and time attributes
+func parseGraphMLExtras(attrs map[string]string) (map[string]interface{}, float64, *time.Time, error) {
+	var data map[string]interface{}
+	if v, ok := attrs["data"]; ok {
+		err := json.Unmarshal([]byte(v), &data)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	var weight float64
+	if v, ok := attrs["weight"]; ok {
+		var err error
+		weight, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	var t *time.Time
+	if v, ok := attrs["time"]; ok {
+		parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		t = &parsed
+	}
+	return data, weight, t, nil
+}
+
+// graphMLAttributes maps the attr.name of the declared keys to their values
+func graphMLAttributes(names map[string]string, data []graphMLData) map[string]string {
+	attrs := make(map[string]string)
+	for _, d := range data {
+		if name, ok := names[d.Key]; ok {
+			attrs[name] = d.Value
+		}
+	}
+	return attrs
+}
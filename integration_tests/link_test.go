@@ -2,9 +2,9 @@ package integration_tests
 
 import (
 	"context"
+	"sync"
 	"testing"
 
-	arango "github.com/arangodb/go-driver"
 	"github.com/stretchr/testify/assert"
 	"github.com/tristanls/sst"
 )
@@ -21,29 +21,24 @@ func TestCreateLink(t *testing.T) {
 	_, err = st.CreateLink(n1, "near", n2, map[string]interface{}{"some": "data"}, 1)
 	assert.NoError(t, err)
 
-	nearLinks, err := db.Collection(context.TODO(), "Near")
+	stored, err := st.GetLink(n1, "near", n2, false)
 	assert.NoError(t, err)
-	var stored sst.Link
-	_, err = nearLinks.ReadDocument(context.TODO(), "+Node_from_nodenearNode_to_node", &stored)
-	assert.NoError(t, err)
-
+	assert.Equal(t, sst.Near, stored.Association.SemanticType)
+	stored.Association = nil
 	assert.Equal(t, sst.Link{
-		Key:    "+Node_from_nodenearNode_to_node",
+		Key:    "+Node%2Ffrom_nodenearNode%2Fto_node",
 		From:   "Node/from_node",
 		To:     "Node/to_node",
 		SID:    "near",
 		Data:   map[string]interface{}{"some": "data"},
 		Weight: 1,
-	}, stored)
+	}, *stored)
 }
 
 func TestDeleteLink(t *testing.T) {
 	db := arangodb(t)
 	defer db.Remove(context.TODO())
 	st := st(t)
-	nearLinks, err := db.Collection(context.TODO(), "Near")
-	containsLinks, err := db.Collection(context.TODO(), "Contains")
-	var link sst.Link
 
 	n1, err := st.CreateNode("Node", "from_node", nil, 1)
 	assert.NoError(t, err)
@@ -51,15 +46,91 @@ func TestDeleteLink(t *testing.T) {
 	assert.NoError(t, err)
 	_, err = st.CreateLink(n1, "near", n2, nil, 1) // n1 --> |near| n2
 	assert.NoError(t, err)
-	_, err = nearLinks.ReadDocument(context.TODO(), "+Node_from_nodenearNode_to_node", &link)
+	_, err = st.GetLink(n1, "near", n2, false)
 	assert.NoError(t, err)
 	_, err = st.CreateLink(n1, "contains", n2, nil, 1) // n1 --> |near| n2, // n1 --> |contains| n2
 	assert.NoError(t, err)
-	_, err = containsLinks.ReadDocument(context.TODO(), "+Node_from_nodecontainsNode_to_node", &link)
+	links, err := st.LinksBetween(n1, n2)
 	assert.NoError(t, err)
+	assert.Len(t, links, 2)
 
 	err = st.DeleteLink(n1, "near", n2, false) // n1 --> |contains| n2
 	assert.NoError(t, err)
-	_, err = nearLinks.ReadDocument(context.TODO(), "+Node_from_nodenearNode_to_node", &link)
-	assert.True(t, arango.IsNotFound(err))
+	_, err = st.GetLink(n1, "near", n2, false)
+	assert.True(t, sst.IsNotFound(err))
+	links, err = st.LinksFrom(n1)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+}
+
+func TestTraverseParallelLinks(t *testing.T) {
+	db := arangodb(t)
+	defer db.Remove(context.TODO())
+	st := st(t)
+
+	n1, err := st.CreateNode("Node", "from_node", nil, 1)
+	assert.NoError(t, err)
+	n2, err := st.CreateNode("Node", "to_node", nil, 1)
+	assert.NoError(t, err)
+	n3, err := st.CreateNode("Node", "next_node", nil, 1)
+	assert.NoError(t, err)
+	_, err = st.CreateLink(n1, "near", n2, nil, 1) // n1 --> |near| n2
+	assert.NoError(t, err)
+	_, err = st.CreateLink(n1, "contains", n2, nil, 1) // n1 --> |contains| n2
+	assert.NoError(t, err)
+	_, err = st.CreateLink(n2, "follows", n3, nil, 1) // n2 --> |follows| n3
+	assert.NoError(t, err)
+	_, err = st.BlockLink(n1, "expresses", n3, nil, 1) // n1 -.-> |expresses| n3
+	assert.NoError(t, err)
+
+	nodes, links, err := st.Traverse(n1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Len(t, links, 2)
+
+	nodes, links, err = st.Traverse(n1, &sst.TraversalOptions{Direction: sst.Any, Depth: 1, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Len(t, links, 3)
+
+	nodes, links, err = st.Traverse(n1, &sst.TraversalOptions{Direction: sst.Any, Depth: 2})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Len(t, links, 3)
+
+	err = st.DeleteNode(n2, true)
+	assert.NoError(t, err)
+	links, err = st.LinksFrom(n1)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	_, err = st.GetLink(n1, "contains", n2, false)
+	assert.True(t, sst.IsNotFound(err))
+}
+
+func TestConcurrentIncrementLink(t *testing.T) {
+	db := arangodb(t)
+	defer db.Remove(context.TODO())
+	st := st(t)
+
+	const writers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			from, err := st.CreateNode("Node", "from_node", nil, 1)
+			assert.NoError(t, err)
+			to, err := st.CreateNode("Node", "to_node", nil, 1)
+			assert.NoError(t, err)
+			_, err = st.IncrementLink(from, "contains", to, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	from := &sst.Node{Prefix: "Node/", Key: "from_node"}
+	to := &sst.Node{Prefix: "Node/", Key: "to_node"}
+	link, err := st.GetLink(from, "contains", to, false)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(writers-1), link.Weight)
 }
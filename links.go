@@ -2,10 +2,10 @@ package sst
 
 import (
 	"context"
-	"fmt"
 	"reflect"
+	"sort"
+	"time"
 
-	arango "github.com/arangodb/go-driver"
 	"github.com/pkg/errors"
 )
 
@@ -31,12 +31,28 @@ type Link struct {
 	Data map[string]interface{} `json:"data,omitempty"`
 	// Weight is the importance rank
 	Weight float64 `json:"weight"`
+	// Time, if set, is the observation time of the link, such as a "then" link between events
+	Time *time.Time `json:"time,omitempty"`
+	// Association is the association designated by SID, resolved by GetLink,
+	// LinksFrom, LinksTo and LinksBetween
+	Association *Association `json:"-"`
+}
+
+// IsUnknownAssociation returns true if the error designates a link of an
+// association that does not exist, false otherwise.
+func IsUnknownAssociation(err error) bool {
+	return errors.Cause(err) == unknownAssociation
 }
 
 // BlockLink creates the negation of the link if it does not exist or updates
 // existing negated link with the new weight.
 func (s *SST) BlockLink(from *Node, rel string, to *Node, data map[string]interface{}, weight float64) (*Link, error) {
-	return s.addLink(linkFrom(from), rel, linkTo(to), data, weight, true)
+	return s.BlockLinkContext(context.Background(), from, rel, to, data, weight)
+}
+
+// BlockLinkContext invokes BlockLink using the provided context
+func (s *SST) BlockLinkContext(ctx context.Context, from *Node, rel string, to *Node, data map[string]interface{}, weight float64) (*Link, error) {
+	return s.addLink(ctx, linkFrom(from), rel, linkTo(to), data, weight, true)
 }
 
 // MustBlockLink invokes BlockLink, but panics on error
@@ -51,7 +67,12 @@ func (s *SST) MustBlockLink(from *Node, rel string, to *Node, data map[string]in
 // BlockLinkByID creates the negation of link if it does not exist or updates
 // existing link negated with the new weight. It uses node IDs to designate link endpoints.
 func (s *SST) BlockLinkByID(fromID, rel, toID string, data map[string]interface{}, weight float64) (*Link, error) {
-	return s.addLink(fromID, rel, toID, data, weight, true)
+	return s.BlockLinkByIDContext(context.Background(), fromID, rel, toID, data, weight)
+}
+
+// BlockLinkByIDContext invokes BlockLinkByID using the provided context
+func (s *SST) BlockLinkByIDContext(ctx context.Context, fromID, rel, toID string, data map[string]interface{}, weight float64) (*Link, error) {
+	return s.addLink(ctx, fromID, rel, toID, data, weight, true)
 }
 
 // MustBlockLinkByID invokes BlockLinkByID, but panics on error
@@ -66,7 +87,12 @@ func (s *SST) MustBlockLinkByID(fromID, rel, toID string, data map[string]interf
 // CreateLink creates the link if it does not exist or updates existing link
 // with the new weight.
 func (s *SST) CreateLink(from *Node, rel string, to *Node, data map[string]interface{}, weight float64) (*Link, error) {
-	return s.CreateLinkByID(linkFrom(from), rel, linkTo(to), data, weight)
+	return s.CreateLinkContext(context.Background(), from, rel, to, data, weight)
+}
+
+// CreateLinkContext invokes CreateLink using the provided context
+func (s *SST) CreateLinkContext(ctx context.Context, from *Node, rel string, to *Node, data map[string]interface{}, weight float64) (*Link, error) {
+	return s.CreateLinkByIDContext(ctx, linkFrom(from), rel, linkTo(to), data, weight)
 }
 
 // MustCreateLink invokes CreateLink, but panics on error
@@ -81,7 +107,12 @@ func (s *SST) MustCreateLink(from *Node, rel string, to *Node, data map[string]i
 // CreateLinkByID creates the link if it does not exist or updates existing link
 // with the new weight. It uses node IDs to designate link endpoints.
 func (s *SST) CreateLinkByID(fromID, rel, toID string, data map[string]interface{}, weight float64) (*Link, error) {
-	return s.addLink(fromID, rel, toID, data, weight, false)
+	return s.CreateLinkByIDContext(context.Background(), fromID, rel, toID, data, weight)
+}
+
+// CreateLinkByIDContext invokes CreateLinkByID using the provided context
+func (s *SST) CreateLinkByIDContext(ctx context.Context, fromID, rel, toID string, data map[string]interface{}, weight float64) (*Link, error) {
+	return s.addLink(ctx, fromID, rel, toID, data, weight, false)
 }
 
 // MustCreateLinkByID invokes CreateLinkByID, but panics on error
@@ -95,21 +126,18 @@ func (s *SST) MustCreateLinkByID(fromID, rel, toID string, data map[string]inter
 
 // DeleteLink deletes the link if it exists.
 func (s *SST) DeleteLink(from *Node, rel string, to *Node, negate bool) error {
-	relKey := ToDocumentKey(rel)
-	association := s.associations[relKey]
+	return s.DeleteLinkContext(context.Background(), from, rel, to, negate)
+}
+
+// DeleteLinkContext deletes the link if it exists using the provided context.
+func (s *SST) DeleteLinkContext(ctx context.Context, from *Node, rel string, to *Node, negate bool) error {
+	relKey := identifierKey(rel)
+	association := s.association(relKey)
 	if association == nil {
-		return errors.New(fmt.Sprintf("sst: invalid link type: %v", relKey))
-	}
-	links, err := s.linksOf(association.SemanticType)
-	if err != nil {
-		return err
+		return errors.Wrapf(unknownAssociation, "sst: invalid link type: %v", relKey)
 	}
 	key := linkKey(linkFrom(from), association.Key, linkTo(to), negate)
-	_, err = links.RemoveDocument(context.TODO(), key)
-	if !arango.IsNotFound(err) {
-		return err
-	}
-	return nil
+	return s.backend.RemoveLink(ctx, association.SemanticType, key)
 }
 
 // MustDeleteLink deletes the link if it exists, but panics on error.
@@ -120,10 +148,114 @@ func (s *SST) MustDeleteLink(from *Node, rel string, to *Node, negate bool) {
 	}
 }
 
+// GetLink retrieves the link, or its negation if negate is true, with its
+// Association resolved.
+func (s *SST) GetLink(from *Node, rel string, to *Node, negate bool) (*Link, error) {
+	return s.GetLinkContext(context.Background(), from, rel, to, negate)
+}
+
+// GetLinkContext retrieves the link using the provided context
+func (s *SST) GetLinkContext(ctx context.Context, from *Node, rel string, to *Node, negate bool) (*Link, error) {
+	relKey := identifierKey(rel)
+	association := s.association(relKey)
+	if association == nil {
+		return nil, errors.Wrapf(unknownAssociation, "sst: invalid link type: %v", relKey)
+	}
+	key := linkKey(linkFrom(from), association.Key, linkTo(to), negate)
+	link, err := s.backend.ReadLink(ctx, association.SemanticType, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to get link: %v", key)
+	}
+	link.Association = association
+	return link, nil
+}
+
+// LinksFrom returns the links from the node, including negated links, of all
+// SemanticTypes with their Association resolved, ordered by SemanticType and key.
+func (s *SST) LinksFrom(node *Node) ([]*Link, error) {
+	return s.LinksFromContext(context.Background(), node)
+}
+
+// LinksFromContext invokes LinksFrom using the provided context
+func (s *SST) LinksFromContext(ctx context.Context, node *Node) ([]*Link, error) {
+	return s.incidentLinks(ctx, node, Outbound, nil)
+}
+
+// LinksTo returns the links to the node, including negated links, of all
+// SemanticTypes with their Association resolved, ordered by SemanticType and key.
+func (s *SST) LinksTo(node *Node) ([]*Link, error) {
+	return s.LinksToContext(context.Background(), node)
+}
+
+// LinksToContext invokes LinksTo using the provided context
+func (s *SST) LinksToContext(ctx context.Context, node *Node) ([]*Link, error) {
+	return s.incidentLinks(ctx, node, Inbound, nil)
+}
+
+// LinksBetween returns the links from a to b and from b to a, including negated
+// links, of all SemanticTypes with their Association resolved, ordered by
+// SemanticType and key.
+func (s *SST) LinksBetween(a, b *Node) ([]*Link, error) {
+	return s.LinksBetweenContext(context.Background(), a, b)
+}
+
+// LinksBetweenContext invokes LinksBetween using the provided context
+func (s *SST) LinksBetweenContext(ctx context.Context, a, b *Node) ([]*Link, error) {
+	other, err := NodeID(b)
+	if err != nil {
+		return nil, err
+	}
+	return s.incidentLinks(ctx, a, Any, func(link *Link) bool {
+		return link.From == other || link.To == other
+	})
+}
+
+// incidentLinks returns the links of the node in the designated direction
+// selected by keep, all links if keep is nil
+func (s *SST) incidentLinks(ctx context.Context, node *Node, dir Direction, keep func(*Link) bool) ([]*Link, error) {
+	id, err := NodeID(node)
+	if err != nil {
+		return nil, err
+	}
+	_, walked, err := s.backend.Traverse(ctx, id, &TraversalOptions{Direction: dir, Depth: 1, IncludeNegated: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to find links of: %v", id)
+	}
+	links := make([]*Link, 0, len(walked))
+	for _, link := range walked {
+		if keep != nil && !keep(link) {
+			continue
+		}
+		link.Association = s.association(link.SID)
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		ti, tj := linkSemanticType(links[i]), linkSemanticType(links[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return links[i].Key < links[j].Key
+	})
+	return links, nil
+}
+
+// linkSemanticType returns the SemanticType of the link collection of the link
+func linkSemanticType(link *Link) SemanticType {
+	if link.Association == nil {
+		return Near
+	}
+	return link.Association.SemanticType.abs()
+}
+
 // IncrementLink creates the link with weight 1.0 if it does not exist or increments
 // the weight of existing link by 1.0.
 func (s *SST) IncrementLink(from *Node, rel string, to *Node, data map[string]interface{}) (*Link, error) {
-	return s.linkOp(linkFrom(from), rel, linkTo(to), data, 0.0, false, incrLinkOp)
+	return s.IncrementLinkContext(context.Background(), from, rel, to, data)
+}
+
+// IncrementLinkContext invokes IncrementLink using the provided context
+func (s *SST) IncrementLinkContext(ctx context.Context, from *Node, rel string, to *Node, data map[string]interface{}) (*Link, error) {
+	return s.linkOp(ctx, linkFrom(from), rel, linkTo(to), data, 0.0, false, IncrementLinkOp)
 }
 
 // MustIncrementLink invokes IncrementLink, but panics on error
@@ -135,33 +267,15 @@ func (s *SST) MustIncrementLink(from *Node, rel string, to *Node, data map[strin
 	return link
 }
 
-// linksOf identifies links collection based on SemanticType needed
-func (s *SST) linksOf(typ SemanticType) (arango.Collection, error) {
-	if typ < 0 {
-		typ *= -1
-	}
-	switch typ {
-	case Near:
-		return s.near, nil
-	case Follows:
-		return s.follows, nil
-	case Contains:
-		return s.contains, nil
-	case Expresses:
-		return s.expresses, nil
-	}
-	return nil, errors.New(fmt.Sprintf("sst: no link collection for semantic type: %v", int(typ)))
-}
-
 // addLink adds the link idempotently.
-func (s *SST) addLink(fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool) (*Link, error) {
-	return s.linkOp(fromID, rel, toID, data, weight, negate, addLinkOp)
+func (s *SST) addLink(ctx context.Context, fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool) (*Link, error) {
+	return s.linkOp(ctx, fromID, rel, toID, data, weight, negate, AddLinkOp)
 }
 
-// addLinkOp determines link when adding a link. Returns link with latest weight or latest data and noop flag.
+// addLinkOp determines link when adding a link. Returns link with latest weight or latest data, or the incumbent link and noop flag.
 func addLinkOp(incumbent, candidate *Link) (*Link, bool) {
 	if candidate.Weight < 0 || incumbent.Weight == candidate.Weight || reflect.DeepEqual(incumbent.Data, candidate.Data) {
-		return nil, true
+		return incumbent, true
 	}
 	return candidate, false
 }
@@ -172,27 +286,56 @@ func incrLinkOp(incumbent, candidate *Link) (*Link, bool) {
 	return candidate, false
 }
 
-type linkOp func(incumbent, candidate *Link) (link *Link, noop bool)
+// LinkOp designates the operation executed on the existing link when upserting
+// a link with the same key. Backends execute LinkOps atomically.
+type LinkOp int
+
+const (
+	// AddLinkOp updates the existing link with the candidate link unless the
+	// candidate has negative weight or the same weight or data as the existing link
+	AddLinkOp LinkOp = iota
+	// IncrementLinkOp updates the existing link with the candidate link, the
+	// weight being the weight of the existing link incremented by 1.0
+	IncrementLinkOp
+)
+
+// apply determines the link to store given the incumbent link and the candidate
+// link with the same key. Returns the incumbent link and noop flag if it is to be kept.
+func (op LinkOp) apply(incumbent, candidate *Link) (*Link, bool) {
+	if op == IncrementLinkOp {
+		return incrLinkOp(incumbent, candidate)
+	}
+	return addLinkOp(incumbent, candidate)
+}
 
 func linkFrom(n *Node) string {
-	return n.Prefix + ToDocumentKey(n.Key)
+	return n.Prefix + documentKey(n.Key)
 }
 func linkKey(from, sid, to string, negate bool) string {
 	if negate {
-		return ToDocumentKey("-" + from + sid + to)
+		return "-" + ToDocumentKey(from+sid+to)
 	}
-	return ToDocumentKey("+" + from + sid + to)
+	return "+" + ToDocumentKey(from+sid+to)
 }
 func linkTo(n *Node) string {
 	return linkFrom(n)
 }
 
 // linkOp creates the link or executes the designated operation on the existing link
-func (s *SST) linkOp(fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool, op linkOp) (*Link, error) {
-	relKey := ToDocumentKey(rel)
-	association := s.associations[relKey]
+func (s *SST) linkOp(ctx context.Context, fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool, op LinkOp) (*Link, error) {
+	association, link, err := s.newLink(fromID, rel, toID, data, weight, negate)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.UpsertLink(ctx, association.SemanticType, link, op)
+}
+
+// newLink creates the link of the designated association
+func (s *SST) newLink(fromID, rel, toID string, data map[string]interface{}, weight float64, negate bool) (*Association, *Link, error) {
+	relKey := identifierKey(rel)
+	association := s.association(relKey)
 	if association == nil {
-		return nil, errors.New(fmt.Sprintf("sst: invalid link type: %v", relKey))
+		return nil, nil, errors.Wrapf(unknownAssociation, "sst: invalid link type: %v", relKey)
 	}
 	link := &Link{
 		From:   fromID,
@@ -202,37 +345,11 @@ func (s *SST) linkOp(fromID, rel, toID string, data map[string]interface{}, weig
 		Weight: weight,
 	}
 	link.Key = linkKey(link.From, link.SID, link.To, negate)
-
-	links, err := s.linksOf(association.SemanticType)
-	if err != nil {
-		return nil, err
-	}
-
-	exists, err := links.DocumentExists(context.TODO(), link.Key)
+	err := s.validateLink(association, link)
 	if err != nil {
-		return nil, err
-	}
-	if !exists {
-		_, err := links.CreateDocument(context.TODO(), link)
-		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to add new link: %v", link)
-		}
-	} else {
-		var existing Link
-		_, err := links.ReadDocument(context.TODO(), link.Key, &existing)
-		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to read link: %v", link.Key)
-		}
-		link, noop := op(&existing, link)
-		if noop {
-			return link, nil
-		}
-		_, err = links.UpdateDocument(context.TODO(), link.Key, link)
-		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to update link: %v", link)
-		}
+		return nil, nil, err
 	}
-	return link, nil
+	return association, link, nil
 }
 
 // LinkNegated returns true if the link is negated, false otherwise.
@@ -266,7 +383,7 @@ func (s *SST) LinkID(link *Link) (string, error) {
 	if link == nil {
 		return "", nilLink
 	}
-	a := s.associations[link.SID]
+	a := s.association(link.SID)
 	if a == nil {
 		return "", unknownAssociation
 	}
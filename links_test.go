@@ -0,0 +1,98 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConcurrentUpserts(t *testing.T, s *SST) {
+	const writers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			from, err := s.CreateNode("Node", "from_node", nil, 1)
+			assert.NoError(t, err)
+			to, err := s.CreateNode("Node", "to_node", nil, 1)
+			assert.NoError(t, err)
+			_, err = s.IncrementLink(from, "contains", to, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	link, err := s.backend.ReadLink(context.TODO(), Contains, linkKey("Node/from_node", "contains", "Node/to_node", false))
+	assert.NoError(t, err)
+	assert.Equal(t, float64(writers-1), link.Weight)
+}
+
+func TestConcurrentUpserts(t *testing.T) {
+	testConcurrentUpserts(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testConcurrentUpserts(t, s)
+}
+
+func TestCreateLinkNoop(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "from_node", nil, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+	created := s.MustCreateLink(n1, "contains", n2, nil, 1)
+	link := s.MustCreateLink(n1, "contains", n2, nil, 1)
+	assert.Equal(t, created, link)
+}
+
+func testLinkLookup(t *testing.T, s *SST) {
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	rome := s.MustCreateNode("Node", "Rome", nil, 1)
+	s.MustCreateLink(france, "contains", paris, map[string]interface{}{"since": "987"}, 2)
+	s.MustCreateLink(paris, "related", france, nil, 1)
+	s.MustBlockLink(paris, "related", rome, nil, 1)
+
+	link, err := s.GetLink(france, "contains", paris, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Node/France", link.From)
+	assert.Equal(t, map[string]interface{}{"since": "987"}, link.Data)
+	assert.Equal(t, 2.0, link.Weight)
+	assert.Equal(t, Contains, link.Association.SemanticType)
+	link, err = s.GetLink(paris, "related", rome, true)
+	assert.NoError(t, err)
+	assert.True(t, MustLinkNegated(link))
+	_, err = s.GetLink(paris, "related", rome, false)
+	assert.True(t, IsNotFound(err))
+	_, err = s.GetLink(paris, "unknown", rome, false)
+	assert.True(t, IsUnknownAssociation(err))
+
+	keys := func(links []*Link, err error) []string {
+		assert.NoError(t, err)
+		res := make([]string, 0, len(links))
+		for _, l := range links {
+			assert.NotNil(t, l.Association)
+			res = append(res, l.Key)
+		}
+		return res
+	}
+	assert.Equal(t, []string{
+		linkKey("Node/Paris", "related", "Node/France", false),
+		linkKey("Node/Paris", "related", "Node/Rome", true),
+	}, keys(s.LinksFrom(paris)))
+	assert.Equal(t, []string{linkKey("Node/France", "contains", "Node/Paris", false)}, keys(s.LinksTo(paris)))
+	assert.Equal(t, []string{
+		linkKey("Node/Paris", "related", "Node/France", false),
+		linkKey("Node/France", "contains", "Node/Paris", false),
+	}, keys(s.LinksBetween(france, paris)))
+	assert.Empty(t, keys(s.LinksBetween(france, rome)))
+}
+
+func TestLinkLookup(t *testing.T) {
+	testLinkLookup(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testLinkLookup(t, s)
+}
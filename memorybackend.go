@@ -0,0 +1,603 @@
+package sst
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/arangodb/go-driver"
+	"github.com/pkg/errors"
+)
+
+var (
+	unsupportedQuery = errors.New("sst: backend does not support queries")
+)
+
+// memoryBackend stores Semantic Spacetime in process memory. Documents are
+// stored JSON encoded so that they read back the same way they would from ArangoDB.
+type memoryBackend struct {
+	mu sync.RWMutex
+
+	associations map[string]*Association
+	events       map[string][]*TimelineEvent
+	timelines    map[string][]string
+	nodes        map[string]map[string][]byte
+	links        map[SemanticType]map[string][]byte
+
+	// from and to index links by the _id of their endpoints
+	from map[string][]linkRef
+	to   map[string][]linkRef
+}
+
+// NewMemoryBackend creates a Backend storing Semantic Spacetime in process memory.
+// Stored spacetime is lost when the process exits.
+func NewMemoryBackend() Backend {
+	return &memoryBackend{}
+}
+
+// Open creates the node collections designated by config.
+func (b *memoryBackend) Open(ctx context.Context, config *Config) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.nodes == nil {
+		b.associations = make(map[string]*Association)
+		b.events = make(map[string][]*TimelineEvent)
+		b.timelines = make(map[string][]string)
+		b.nodes = make(map[string]map[string][]byte)
+		b.links = make(map[SemanticType]map[string][]byte)
+		for _, typ := range linkTypes(nil) {
+			b.links[typ] = make(map[string][]byte)
+		}
+		b.from = make(map[string][]linkRef)
+		b.to = make(map[string][]linkRef)
+	}
+	for _, kind := range config.NodeCollections {
+		if b.nodes[kind] == nil {
+			b.nodes[kind] = make(map[string][]byte)
+		}
+	}
+	return nil
+}
+
+// Close is a noop, memory is released once the backend is no longer referenced.
+func (b *memoryBackend) Close() error {
+	return nil
+}
+
+// UpsertNode idempotently inserts the node into the collection specified by node.Prefix
+func (b *memoryBackend) UpsertNode(ctx context.Context, node *Node) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.upsertNode(node)
+}
+
+// UpsertNodes idempotently inserts the nodes in order
+func (b *memoryBackend) UpsertNodes(ctx context.Context, nodes []*Node) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	errs := make(BatchError, len(nodes))
+	for i, node := range nodes {
+		errs[i] = b.upsertNode(node)
+	}
+	return errs.orNil()
+}
+
+// ReadNode reads the node with the designated _id
+func (b *memoryBackend) ReadNode(ctx context.Context, id string) (*Node, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.readNode(id)
+}
+
+// RemoveNode removes the node with the designated _id if it exists
+func (b *memoryBackend) RemoveNode(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.removeNode(id)
+}
+
+// ReadNodeCollections reads the names of the node collections
+func (b *memoryBackend) ReadNodeCollections(ctx context.Context) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	names := make([]string, 0, len(b.nodes))
+	for name := range b.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AddNodeCollection creates the named node collection if it does not exist
+func (b *memoryBackend) AddNodeCollection(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.nodes[name] == nil {
+		b.nodes[name] = make(map[string][]byte)
+	}
+	return nil
+}
+
+// RemoveNodeCollection removes the named node collection and its nodes
+func (b *memoryBackend) RemoveNodeCollection(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.nodes, name)
+	return nil
+}
+
+// ReadNodes reads the nodes of the named collection ordered by _key
+func (b *memoryBackend) ReadNodes(ctx context.Context, collection string) ([]*Node, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	nodes, err := b.collectionOf(collection + "/")
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Node, 0, len(nodes))
+	for _, key := range sortedKeys(nodes) {
+		node, err := decodeNode(nodes[key], collection+"/"+key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, node)
+	}
+	return res, nil
+}
+
+// ListNodes reads the page of the nodes of the named collection selected by the filter
+func (b *memoryBackend) ListNodes(ctx context.Context, collection string, filter *NodeFilter, page *Page) ([]*Node, error) {
+	nodes, err := b.ReadNodes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return selectNodes(nodes, filter, page), nil
+}
+
+// UpsertLink creates the link or executes the designated operation on the existing link
+func (b *memoryBackend) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.upsertLink(typ, link, op)
+}
+
+// UpsertLinks creates the links or executes the designated operation on the existing links in order
+func (b *memoryBackend) UpsertLinks(ctx context.Context, types []SemanticType, links []*Link, op LinkOp) ([]*Link, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stored := make([]*Link, len(links))
+	errs := make(BatchError, len(links))
+	for i, link := range links {
+		stored[i], errs[i] = b.upsertLink(types[i], link, op)
+	}
+	return stored, errs.orNil()
+}
+
+// ReadLink reads the link with the designated key
+func (b *memoryBackend) ReadLink(ctx context.Context, typ SemanticType, key string) (*Link, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	return readLink(links, key)
+}
+
+// RemoveLink removes the link with the designated key if it exists
+func (b *memoryBackend) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.removeLink(typ, key)
+}
+
+// ReadLinks reads the links of the collection of the designated SemanticType ordered by _key
+func (b *memoryBackend) ReadLinks(ctx context.Context, typ SemanticType) ([]*Link, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Link, 0, len(links))
+	for _, key := range sortedKeys(links) {
+		link, err := decodeLink(links[key], key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, link)
+	}
+	return res, nil
+}
+
+// ReadAssociations reads all stored associations
+func (b *memoryBackend) ReadAssociations(ctx context.Context) (map[string]*Association, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	associations := make(map[string]*Association, len(b.associations))
+	for k, v := range b.associations {
+		a := *v
+		associations[k] = &a
+	}
+	return associations, nil
+}
+
+// InsertAssociation stores the association unless an association with the same key is already stored
+func (b *memoryBackend) InsertAssociation(ctx context.Context, a *Association) (*Association, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	existing := b.associations[a.Key]
+	if existing == nil {
+		existing = &Association{}
+		*existing = *a
+		b.associations[a.Key] = existing
+	}
+	stored := *existing
+	return &stored, nil
+}
+
+// ReadTimeline reads the head of the named timeline
+func (b *memoryBackend) ReadTimeline(ctx context.Context, name string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]string(nil), b.timelines[name]...), nil
+}
+
+// WriteTimeline stores the head of the named timeline
+func (b *memoryBackend) WriteTimeline(ctx context.Context, name string, head []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timelines[name] = append([]string(nil), head...)
+	return nil
+}
+
+// AppendEvents records the observation of events keeping events of a timeline ordered
+func (b *memoryBackend) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.appendEvents(timeline, events)
+	return nil
+}
+
+// ReadEvents reads events observed within the designated time range
+func (b *memoryBackend) ReadEvents(ctx context.Context, timeline string, from, to time.Time) ([]*TimelineEvent, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	events := make([]*TimelineEvent, 0)
+	for _, e := range b.events[timeline] {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			events = append(events, &TimelineEvent{ID: e.ID, Time: e.Time})
+		}
+	}
+	return events, nil
+}
+
+// ReadActiveEvents reads the latest events observed at or before the designated time
+func (b *memoryBackend) ReadActiveEvents(ctx context.Context, timeline string, at time.Time) ([]*TimelineEvent, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	recorded := b.events[timeline]
+	end := sort.Search(len(recorded), func(i int) bool {
+		return recorded[i].Time.After(at)
+	})
+	start := end
+	for start > 0 && recorded[start-1].Time.Equal(recorded[end-1].Time) {
+		start--
+	}
+	events := make([]*TimelineEvent, 0, end-start)
+	for _, e := range recorded[start:end] {
+		events = append(events, &TimelineEvent{ID: e.ID, Time: e.Time})
+	}
+	return events, nil
+}
+
+// Begin starts a transaction holding the lock of the backend until it is
+// committed or aborted. Writes are applied as they are executed and undone if
+// the transaction is aborted.
+func (b *memoryBackend) Begin(ctx context.Context) (BackendTx, error) {
+	b.mu.Lock()
+	return &memoryTx{b: b}, nil
+}
+
+// Traverse walks the graph breadth-first using the link indexes
+func (b *memoryBackend) Traverse(ctx context.Context, startID string, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	opts = traversalOptions(opts)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, typ := range opts.SemanticTypes {
+		if _, err := b.linksOf(typ); err != nil {
+			return nil, nil, err
+		}
+	}
+	return walk(b, startID, opts)
+}
+
+// ApplySchema does nothing, the memory backend has no schema validation
+func (b *memoryBackend) ApplySchema(ctx context.Context, schema *Schema, associations []*Association) error {
+	return nil
+}
+
+// Query is not supported by the memory backend
+func (b *memoryBackend) Query(ctx context.Context, query string, vars map[string]interface{}) (driver.Cursor, error) {
+	return nil, unsupportedQuery
+}
+
+// upsertNode idempotently inserts the node, callers must hold the lock
+func (b *memoryBackend) upsertNode(node *Node) error {
+	nodes, err := b.collectionOf(node.Prefix)
+	if err != nil {
+		return err
+	}
+	doc, err := upsertNodeDocument(nodes[node.Key], node)
+	if err != nil {
+		return err
+	}
+	if doc != nil {
+		nodes[node.Key] = doc
+	}
+	return nil
+}
+
+// upsertLink creates the link or executes op on the existing link, callers must hold the lock
+func (b *memoryBackend) upsertLink(typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	stored, exists := links[link.Key]
+	doc, link, err := upsertLinkDocument(stored, link, op)
+	if err != nil {
+		return nil, err
+	}
+	if doc != nil {
+		links[link.Key] = doc
+	}
+	if !exists {
+		ref := linkRef{typ: typ.abs(), key: link.Key}
+		b.from[link.From] = append(b.from[link.From], ref)
+		b.to[link.To] = append(b.to[link.To], ref)
+	}
+	return link, nil
+}
+
+// removeNode removes the node if it exists, callers must hold the lock
+func (b *memoryBackend) removeNode(id string) error {
+	kind, key := splitNodeID(id)
+	nodes, err := b.collectionOf(kind + "/")
+	if err != nil {
+		return err
+	}
+	delete(nodes, key)
+	return nil
+}
+
+// removeLink removes the link if it exists, callers must hold the lock
+func (b *memoryBackend) removeLink(typ SemanticType, key string) error {
+	links, err := b.linksOf(typ)
+	if err != nil {
+		return err
+	}
+	link, err := readLink(links, key)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(links, key)
+	ref := linkRef{typ: typ.abs(), key: key}
+	b.from[link.From] = removeLinkRef(b.from[link.From], ref)
+	b.to[link.To] = removeLinkRef(b.to[link.To], ref)
+	return nil
+}
+
+// appendEvents records the observation of events, callers must hold the lock
+func (b *memoryBackend) appendEvents(timeline string, events []*TimelineEvent) {
+	recorded := b.events[timeline]
+	for _, e := range events {
+		i := sort.Search(len(recorded), func(i int) bool {
+			return eventBefore(e, recorded[i])
+		})
+		recorded = append(recorded, nil)
+		copy(recorded[i+1:], recorded[i:])
+		recorded[i] = &TimelineEvent{ID: e.ID, Time: e.Time}
+	}
+	b.events[timeline] = recorded
+}
+
+// readNode reads the node with the designated _id, callers must hold the lock
+func (b *memoryBackend) readNode(id string) (*Node, error) {
+	kind, key := splitNodeID(id)
+	nodes, err := b.collectionOf(kind + "/")
+	if err != nil {
+		return nil, err
+	}
+	stored, exists := nodes[key]
+	if !exists {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no node for id: %v", id)
+	}
+	return decodeNode(stored, id)
+}
+
+// adjacent returns the links from or to the node with the designated _id, callers must hold the lock
+func (b *memoryBackend) adjacent(id string, dir Direction) ([]linkRef, error) {
+	refs := make([]linkRef, 0)
+	if dir == Outbound || dir == Any {
+		refs = append(refs, b.from[id]...)
+	}
+	if dir == Inbound || dir == Any {
+		refs = append(refs, b.to[id]...)
+	}
+	return refs, nil
+}
+
+// readLinkRef reads the designated link, callers must hold the lock
+func (b *memoryBackend) readLinkRef(ref linkRef) (*Link, error) {
+	return readLink(b.links[ref.typ], ref.key)
+}
+
+// collectionOf identifies node collection based on node prefix
+func (b *memoryBackend) collectionOf(prefix string) (map[string][]byte, error) {
+	var col map[string][]byte
+	if len(prefix) > 0 {
+		col = b.nodes[prefix[:len(prefix)-1]]
+	}
+	if col == nil {
+		return nil, errors.New(fmt.Sprintf("sst: no node collection for prefix: %v", prefix))
+	}
+	return col, nil
+}
+
+// linksOf identifies links collection based on SemanticType needed
+func (b *memoryBackend) linksOf(typ SemanticType) (map[string][]byte, error) {
+	col := b.links[typ.abs()]
+	if col == nil {
+		return nil, errors.New(fmt.Sprintf("sst: no link collection for semantic type: %v", int(typ)))
+	}
+	return col, nil
+}
+
+// sortedKeys returns the keys of the documents in order
+func sortedKeys(docs map[string][]byte) []string {
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readLink decodes the link with the designated key from the links collection
+func readLink(links map[string][]byte, key string) (*Link, error) {
+	stored, exists := links[key]
+	if !exists {
+		return nil, errors.Wrapf(ErrNotFound, "sst: no link for key: %v", key)
+	}
+	return decodeLink(stored, key)
+}
+
+// memoryTx executes writes while holding the lock of the memory backend,
+// recording how to undo each write
+type memoryTx struct {
+	b    *memoryBackend
+	done bool
+	undo []func()
+}
+
+// UpsertNode idempotently inserts the node
+func (t *memoryTx) UpsertNode(ctx context.Context, node *Node) error {
+	if nodes, err := t.b.collectionOf(node.Prefix); err == nil {
+		t.saveDocument(nodes, node.Key)
+	}
+	return t.b.upsertNode(node)
+}
+
+// RemoveNode removes the node with the designated _id if it exists
+func (t *memoryTx) RemoveNode(ctx context.Context, id string) error {
+	kind, key := splitNodeID(id)
+	if nodes, err := t.b.collectionOf(kind + "/"); err == nil {
+		t.saveDocument(nodes, key)
+	}
+	return t.b.removeNode(id)
+}
+
+// UpsertLink creates the link or executes the designated operation on the existing link
+func (t *memoryTx) UpsertLink(ctx context.Context, typ SemanticType, link *Link, op LinkOp) (*Link, error) {
+	t.saveLink(typ, link.Key, link.From, link.To)
+	return t.b.upsertLink(typ, link, op)
+}
+
+// RemoveLink removes the link with the designated key if it exists
+func (t *memoryTx) RemoveLink(ctx context.Context, typ SemanticType, key string) error {
+	if links, err := t.b.linksOf(typ); err == nil {
+		if link, err := readLink(links, key); err == nil {
+			t.saveLink(typ, key, link.From, link.To)
+		}
+	}
+	return t.b.removeLink(typ, key)
+}
+
+// WriteTimeline stores the head of the named timeline
+func (t *memoryTx) WriteTimeline(ctx context.Context, name string, head []string) error {
+	prev, existed := t.b.timelines[name]
+	t.undo = append(t.undo, func() {
+		if existed {
+			t.b.timelines[name] = prev
+		} else {
+			delete(t.b.timelines, name)
+		}
+	})
+	t.b.timelines[name] = append([]string(nil), head...)
+	return nil
+}
+
+// AppendEvents records the observation of events
+func (t *memoryTx) AppendEvents(ctx context.Context, timeline string, events []*TimelineEvent) error {
+	prev := append([]*TimelineEvent(nil), t.b.events[timeline]...)
+	t.undo = append(t.undo, func() {
+		t.b.events[timeline] = prev
+	})
+	t.b.appendEvents(timeline, events)
+	return nil
+}
+
+// Commit keeps the writes and releases the lock of the backend
+func (t *memoryTx) Commit(ctx context.Context) error {
+	if !t.done {
+		t.done = true
+		t.b.mu.Unlock()
+	}
+	return nil
+}
+
+// Abort undoes the writes and releases the lock of the backend
+func (t *memoryTx) Abort(ctx context.Context) error {
+	if !t.done {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+		t.done = true
+		t.b.mu.Unlock()
+	}
+	return nil
+}
+
+// saveDocument records how to restore the document with the designated key
+func (t *memoryTx) saveDocument(docs map[string][]byte, key string) {
+	prev, existed := docs[key]
+	t.undo = append(t.undo, func() {
+		if existed {
+			docs[key] = prev
+		} else {
+			delete(docs, key)
+		}
+	})
+}
+
+// saveLink records how to restore the link with the designated key and the
+// indexes of its endpoints
+func (t *memoryTx) saveLink(typ SemanticType, key, fromID, toID string) {
+	links, err := t.b.linksOf(typ)
+	if err != nil {
+		return
+	}
+	t.saveDocument(links, key)
+	from := append([]linkRef(nil), t.b.from[fromID]...)
+	to := append([]linkRef(nil), t.b.to[toID]...)
+	t.undo = append(t.undo, func() {
+		t.b.from[fromID] = from
+		t.b.to[toID] = to
+	})
+}
+
+// removeLinkRef removes ref from refs
+func removeLinkRef(refs []linkRef, ref linkRef) []linkRef {
+	for i := range refs {
+		if refs[i] == ref {
+			return append(refs[:i], refs[i+1:]...)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,130 @@
+package sst
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func memorySST(t *testing.T) *SST {
+	s, err := NewSST(&Config{
+		Backend:         NewMemoryBackend(),
+		Name:            "memory",
+		NodeCollections: []string{"Node"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	return s
+}
+
+func TestMemoryCreateNode(t *testing.T) {
+	s := memorySST(t)
+
+	n, err := s.CreateNode("Node", "my node", map[string]interface{}{"some": "data"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "my%20node", n.Key)
+
+	data, err := s.GetNodeData("Node/my node")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"some": "data"}, data)
+
+	_, err = s.CreateNode("Node", "my node", nil, 0)
+	assert.NoError(t, err)
+	data, err = s.GetNodeData("Node/my node")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"some": "data"}, data)
+
+	_, err = s.CreateNode("Node", "my node", map[string]interface{}{"more": "data"}, 2)
+	assert.NoError(t, err)
+	data, err = s.GetNodeData("Node/my node")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"some": "data", "more": "data"}, data)
+	_, err = s.GetNodeData("Node/my%20node")
+	assert.True(t, IsNotFound(err))
+
+	_, err = s.CreateNode("Unknown", "my node", nil, 0)
+	assert.Error(t, err)
+	_, err = s.GetNodeData("Node/missing")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestMemoryCreateLink(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "from_node", nil, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+
+	link, err := s.CreateLink(n1, "related", n2, map[string]interface{}{"some": "data"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &Link{
+		Key:    "+Node%2Ffrom_noderelatedNode%2Fto_node",
+		From:   "Node/from_node",
+		To:     "Node/to_node",
+		SID:    "related",
+		Data:   map[string]interface{}{"some": "data"},
+		Weight: 1,
+	}, link)
+
+	stored, err := s.backend.ReadLink(context.TODO(), Near, link.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, link, stored)
+}
+
+func TestMemoryIncrementLink(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "from_node", nil, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+
+	s.MustIncrementLink(n1, "contains", n2, nil)
+	link := s.MustIncrementLink(n1, "contains", n2, nil)
+	assert.Equal(t, 1.0, link.Weight)
+
+	stored, err := s.backend.ReadLink(context.TODO(), Contains, link.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, stored.Weight)
+}
+
+func TestMemoryDeleteLink(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "from_node", nil, 1)
+	n2 := s.MustCreateNode("Node", "to_node", nil, 1)
+	s.MustCreateLink(n1, "related", n2, nil, 1)
+	s.MustCreateLink(n1, "contains", n2, nil, 1)
+
+	err := s.DeleteLink(n1, "related", n2, false)
+	assert.NoError(t, err)
+	_, err = s.backend.ReadLink(context.TODO(), Near, "+Node%2Ffrom_noderelatedNode%2Fto_node")
+	assert.True(t, IsNotFound(err))
+	_, err = s.backend.ReadLink(context.TODO(), Contains, "+Node%2Ffrom_nodecontainsNode%2Fto_node")
+	assert.NoError(t, err)
+
+	err = s.DeleteLink(n1, "related", n2, false)
+	assert.NoError(t, err)
+}
+
+func TestMemoryTraverse(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "n1", nil, 1)
+	n2 := s.MustCreateNode("Node", "n2", nil, 1)
+	n3 := s.MustCreateNode("Node", "n3", nil, 1)
+	s.MustCreateLink(n1, "contains", n2, nil, 1)
+	s.MustCreateLink(n2, "contains", n3, nil, 1)
+	s.MustCreateLink(n1, "related", n3, nil, 1)
+
+	nodes, links, err := s.backend.Traverse(context.TODO(), "Node/n1", &TraversalOptions{SemanticTypes: []SemanticType{Contains}, Direction: Outbound, Depth: 1})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, "n2", nodes[0].Key)
+	assert.Len(t, links, 1)
+
+	nodes, links, err = s.backend.Traverse(context.TODO(), "Node/n1", &TraversalOptions{SemanticTypes: []SemanticType{Contains}, Direction: Outbound, Depth: 2})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Len(t, links, 2)
+
+	nodes, links, err = s.backend.Traverse(context.TODO(), "Node/n3", &TraversalOptions{Direction: Inbound, Depth: 1})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Len(t, links, 2)
+}
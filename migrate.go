@@ -0,0 +1,151 @@
+package sst
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	documentKeyCollision = errors.New("sst: document key collision")
+)
+
+// MigrateDocumentKeys migrates the nodes and links of a spacetime created before
+// nodes stored their name and keys were encoded by ToDocumentKey. Each node
+// without a name is named after its key, the names of nodes whose keys were
+// created by replacing disallowed characters with '_' cannot be recovered. Each
+// node whose key is not ToDocumentKey of its name is then re-keyed, its links
+// being re-keyed to link the re-keyed node. A node is not re-keyed if a node with
+// its new key exists. Events, whose _ids are recorded by timelines, are named but
+// not re-keyed. Each remaining link whose key was created by replacing
+// disallowed characters with '_' is re-keyed unless a link with its new key
+// exists. Returns the number of nodes and links migrated and a BatchError
+// reporting the nodes and links that failed.
+func (s *SST) MigrateDocumentKeys() (int, error) {
+	return s.MigrateDocumentKeysContext(context.Background())
+}
+
+// MigrateDocumentKeysContext invokes MigrateDocumentKeys using the provided context
+func (s *SST) MigrateDocumentKeysContext(ctx context.Context) (int, error) {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	legacy := make([]*Node, 0)
+	for _, name := range collections {
+		nodes, err := s.backend.ReadNodes(ctx, name)
+		if err != nil {
+			return 0, err
+		}
+		for _, node := range nodes {
+			if node.Name == "" || (node.Time == nil && node.Key != ToDocumentKey(node.Name)) {
+				node.Prefix = name + "/"
+				legacy = append(legacy, node)
+			}
+		}
+	}
+	errs := make(BatchError, 0, len(legacy))
+	migrated := 0
+	for _, node := range legacy {
+		err := s.migrateDocumentKey(ctx, node)
+		if err == nil {
+			migrated++
+		}
+		errs = append(errs, err)
+	}
+	for _, typ := range linkTypes(nil) {
+		links, err := s.backend.ReadLinks(ctx, typ)
+		if err != nil {
+			return migrated, err
+		}
+		for _, link := range links {
+			key := linkKey(link.From, link.SID, link.To, MustLinkKeyNegated(link.Key))
+			if key == link.Key {
+				continue
+			}
+			err := s.migrateLinkKey(ctx, typ, link, key)
+			if err == nil {
+				migrated++
+			}
+			errs = append(errs, err)
+		}
+	}
+	return migrated, errs.orNil()
+}
+
+// migrateDocumentKey names the node and re-keys it, and its links, unless it is
+// an event
+func (s *SST) migrateDocumentKey(ctx context.Context, node *Node) error {
+	if node.Name == "" {
+		node.Name = node.Key
+	}
+	key := ToDocumentKey(node.Name)
+	if key == node.Key || node.Time != nil {
+		return s.backend.UpsertNode(ctx, node)
+	}
+
+	oldID, newID := node.Prefix+node.Key, node.Prefix+key
+	_, err := s.backend.ReadNode(ctx, newID)
+	if err == nil {
+		return errors.Wrapf(documentKeyCollision, "sst: cannot migrate node %v to existing node: %v", oldID, newID)
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+	_, links, err := s.backend.Traverse(ctx, oldID, &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to find links of: %v", oldID)
+	}
+	return s.update(ctx, func(tx BackendTx) error {
+		rekeyed := *node
+		rekeyed.Key = key
+		err := tx.UpsertNode(ctx, &rekeyed)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			a := s.association(link.SID)
+			if a == nil {
+				return errors.Wrapf(unknownAssociation, "sst: cannot migrate link: %v", link.Key)
+			}
+			moved := *link
+			if moved.From == oldID {
+				moved.From = newID
+			}
+			if moved.To == oldID {
+				moved.To = newID
+			}
+			moved.Key = linkKey(moved.From, moved.SID, moved.To, MustLinkKeyNegated(link.Key))
+			err := tx.RemoveLink(ctx, a.SemanticType, link.Key)
+			if err != nil {
+				return err
+			}
+			_, err = tx.UpsertLink(ctx, a.SemanticType, &moved, AddLinkOp)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.RemoveNode(ctx, oldID)
+	})
+}
+
+// migrateLinkKey re-keys the link unless a link with its new key exists
+func (s *SST) migrateLinkKey(ctx context.Context, typ SemanticType, link *Link, key string) error {
+	_, err := s.backend.ReadLink(ctx, typ, key)
+	if err == nil {
+		return errors.Wrapf(documentKeyCollision, "sst: cannot migrate link %v to existing link: %v", link.Key, key)
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+	return s.update(ctx, func(tx BackendTx) error {
+		moved := *link
+		moved.Key = key
+		err := tx.RemoveLink(ctx, typ, link.Key)
+		if err != nil {
+			return err
+		}
+		_, err = tx.UpsertLink(ctx, typ, &moved, AddLinkOp)
+		return err
+	})
+}
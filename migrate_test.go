@@ -0,0 +1,75 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMigrateDocumentKeys(t *testing.T, s *SST) {
+	ctx := context.TODO()
+	err := s.backend.UpsertNodes(ctx, []*Node{
+		{Prefix: "Node/", Key: "Paris", Weight: 1},
+		{Prefix: "Node/", Key: "100%", Data: map[string]interface{}{"unit": "percent"}, Weight: 2},
+		{Prefix: "Node/", Key: "A%20B", Weight: 1},
+		{Prefix: "Node/", Key: "Tokyo", Name: "東京", Weight: 1},
+		{Prefix: "Node/", Key: "%E6%9D%B1%E4%BA%AC", Name: "東京", Weight: 1},
+	})
+	assert.NoError(t, err)
+	s.MustCreateNode("Node", "Rome", nil, 1)
+	s.MustCreateLinkByID("Node/100%", "related", "Node/Paris", nil, 1)
+	s.MustBlockLinkByID("Node/Paris", "contains", "Node/100%", nil, 3)
+	_, err = s.backend.UpsertLink(ctx, Near, &Link{Key: "+Node_RomerelatedNode_Paris", From: "Node/Rome", To: "Node/Paris", SID: "related", Weight: 2}, AddLinkOp)
+	assert.NoError(t, err)
+
+	migrated, err := s.MigrateDocumentKeys()
+	assert.Equal(t, 4, migrated)
+	errs := err.(BatchError)
+	assert.Len(t, errs, 5)
+	assert.Equal(t, documentKeyCollision, errors.Cause(errs[3]))
+	assert.NoError(t, errs[4])
+
+	n, err := s.backend.ReadNode(ctx, "Node/Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris", n.Name)
+	_, err = s.backend.ReadNode(ctx, "Node/100%")
+	assert.True(t, IsNotFound(err))
+	n, err = s.GetNode("Node/100%")
+	assert.NoError(t, err)
+	assert.Equal(t, &Node{Prefix: "Node/", Key: "100%25", Name: "100%", Data: map[string]interface{}{"unit": "percent"}, Weight: 2}, n)
+	n, err = s.backend.ReadNode(ctx, "Node/A%2520B")
+	assert.NoError(t, err)
+	assert.Equal(t, "A%20B", n.Name)
+	_, err = s.backend.ReadNode(ctx, "Node/Tokyo")
+	assert.NoError(t, err)
+
+	links, err := s.LinksFrom(&Node{Prefix: "Node/", Key: "100%25"})
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "Node/Paris", links[0].To)
+	link, err := s.GetLink(&Node{Prefix: "Node/", Key: "Paris"}, "contains", &Node{Prefix: "Node/", Key: "100%25"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, link.Weight)
+	_, links, err = s.backend.Traverse(ctx, "Node/100%", &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Empty(t, links)
+	link, err = s.GetLink(&Node{Prefix: "Node/", Key: "Rome"}, "related", &Node{Prefix: "Node/", Key: "Paris"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, link.Weight)
+	_, err = s.backend.ReadLink(ctx, Near, "+Node_RomerelatedNode_Paris")
+	assert.True(t, IsNotFound(err))
+
+	migrated, err = s.MigrateDocumentKeys()
+	assert.Equal(t, 0, migrated)
+	assert.Equal(t, documentKeyCollision, errors.Cause(err.(BatchError)[0]))
+}
+
+func TestMigrateDocumentKeys(t *testing.T) {
+	testMigrateDocumentKeys(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testMigrateDocumentKeys(t, s)
+}
@@ -2,33 +2,85 @@ package sst
 
 import (
 	"context"
-	"fmt"
-	"path"
-	"reflect"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
-	arango "github.com/arangodb/go-driver"
 	"github.com/pkg/errors"
 )
 
 var (
-	nilNode = errors.New("sst: node is nil")
+	invalidNodeCollection  = errors.New("sst: invalid node collection name")
+	nilNode                = errors.New("sst: node is nil")
+	nodeCollectionNotEmpty = errors.New("sst: node collection is not empty")
+	nodeHasLinks           = errors.New("sst: node has links")
+
+	collectionRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,255}$`)
 )
 
+// disconnectedCollection is the orphan vertex collection of ArangoDB graphs,
+// it is never removed by reconciliation
+const disconnectedCollection = "Disconnected"
+
 // Node represents a vertex of a Semantic Spacetime graph
 type Node struct {
-	// Key is a mandatory field - short name
+	// Key is a mandatory field - short name, the document key encoded from Name
+	// by ToDocumentKey. A Key with characters disallowed in document keys
+	// designates the node whose key is encoded from it.
 	Key string `json:"_key"`
+	// Name is the human-readable name of the node
+	Name string `json:"name,omitempty"`
 	// Data is an arbitrary key value data structure serializable to JSON
 	Data map[string]interface{} `json:"data,omitempty"`
 	// Prefix designates node collection origin
 	Prefix string
 	// Weight is the importance rank
 	Weight float64 `json:"weight"`
+	// Time, if set, is the latest observation time of the node, such as an event
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// NodeFilter selects nodes, the zero NodeFilter selects all nodes
+type NodeFilter struct {
+	// NamePrefix, if set, selects nodes whose name, or key if stored without
+	// name, starts with NamePrefix
+	NamePrefix string
+	// MinWeight, if not zero, selects nodes weighing at least MinWeight
+	MinWeight float64
+	// Data selects nodes whose data holds the designated values
+	Data map[string]interface{}
+}
+
+// Page designates a page of an ordered listing
+type Page struct {
+	// Offset is the number of items skipped
+	Offset int
+	// Limit is the maximum number of items, all remaining items if zero
+	Limit int
+}
+
+// IsNodeHasLinks returns true if the error designates a node that was not
+// deleted because it has links, false otherwise.
+func IsNodeHasLinks(err error) bool {
+	return errors.Cause(err) == nodeHasLinks
+}
+
+// IsNodeCollectionNotEmpty returns true if the error designates a node
+// collection that was not removed because it holds nodes, false otherwise.
+func IsNodeCollectionNotEmpty(err error) bool {
+	return errors.Cause(err) == nodeCollectionNotEmpty
 }
 
 // CreateNode idempotently creates a node of the specified kind
 func (s *SST) CreateNode(kind, key string, data map[string]interface{}, weight float64) (*Node, error) {
-	return s.createNode(kind+"/", key, data, weight)
+	return s.CreateNodeContext(context.Background(), kind, key, data, weight)
+}
+
+// CreateNodeContext idempotently creates a node of the specified kind using the provided context
+func (s *SST) CreateNodeContext(ctx context.Context, kind, key string, data map[string]interface{}, weight float64) (*Node, error) {
+	return s.createNode(ctx, kind+"/", key, data, weight)
 }
 
 // MustCreateNode idempotently creates a node of the specified kind, panics on error
@@ -40,33 +92,212 @@ func (s *SST) MustCreateNode(kind string, key string, data map[string]interface{
 	return node
 }
 
-// GetNodeData retrieves data of the node for designated key
-func (s *SST) GetNodeData(key string) (map[string]interface{}, error) {
-	prefix := path.Dir(key)
-	rawkey := path.Base(key)
+// GetNodeData retrieves data of the node of the designated kind and name, e.g.
+// "Node/New York". The name is encoded by ToDocumentKey, see Node.Key.
+func (s *SST) GetNodeData(id string) (map[string]interface{}, error) {
+	return s.GetNodeDataContext(context.Background(), id)
+}
 
-	col, err := s.collectionOf(prefix + "/") // TODO: verify this works
+// GetNodeDataContext retrieves data of the node for designated _id using the provided context
+func (s *SST) GetNodeDataContext(ctx context.Context, id string) (map[string]interface{}, error) {
+	kind, name := splitNodeID(id)
+	id = kind + "/" + ToDocumentKey(name)
+	node, err := s.backend.ReadNode(ctx, id)
 	if err != nil {
-		return nil, errors.Wrapf(err, "sst: failed to get node collection for key: %v", key)
+		return nil, errors.Wrapf(err, "sst: failed to get node for key: %v", id)
 	}
+	return node.Data, nil
+}
 
-	var node Node
-	_, err = col.ReadDocument(context.TODO(), rawkey, &node)
+// GetNode retrieves the node of the designated kind and name, e.g.
+// "Node/New York", with Prefix populated. The name is encoded by ToDocumentKey,
+// see Node.Key.
+func (s *SST) GetNode(id string) (*Node, error) {
+	return s.GetNodeContext(context.Background(), id)
+}
+
+// GetNodeContext retrieves the node for the designated _id using the provided context
+func (s *SST) GetNodeContext(ctx context.Context, id string) (*Node, error) {
+	kind, name := splitNodeID(id)
+	id = kind + "/" + ToDocumentKey(name)
+	node, err := s.backend.ReadNode(ctx, id)
 	if err != nil {
-		return nil, errors.Wrapf(err, "sst: failed to get node for key: %v", key)
+		return nil, errors.Wrapf(err, "sst: failed to get node for key: %v", id)
 	}
-	return node.Data, nil
+	node.Prefix = kind + "/"
+	return node, nil
+}
+
+// ListNodes lists the nodes of the specified kind selected by the filter,
+// ordered by key. A nil filter selects all nodes, a nil page lists all of them.
+func (s *SST) ListNodes(kind string, filter *NodeFilter, page *Page) ([]*Node, error) {
+	return s.ListNodesContext(context.Background(), kind, filter, page)
+}
+
+// ListNodesContext lists the nodes of the specified kind using the provided context
+func (s *SST) ListNodesContext(ctx context.Context, kind string, filter *NodeFilter, page *Page) ([]*Node, error) {
+	if filter == nil {
+		filter = &NodeFilter{}
+	}
+	if page == nil {
+		page = &Page{}
+	}
+	nodes, err := s.backend.ListNodes(ctx, kind, filter, page)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to list nodes of: %v", kind)
+	}
+	for _, node := range nodes {
+		node.Prefix = kind + "/"
+	}
+	return nodes, nil
+}
+
+// DeleteNode deletes the node if it exists. Links from or to the node, including
+// negated links, are deleted with the node if cascade is true, otherwise a node
+// with links is not deleted and an error is returned.
+func (s *SST) DeleteNode(node *Node, cascade bool) error {
+	return s.DeleteNodeContext(context.Background(), node, cascade)
+}
+
+// DeleteNodeContext deletes the node if it exists using the provided context
+func (s *SST) DeleteNodeContext(ctx context.Context, node *Node, cascade bool) error {
+	id, err := NodeID(node)
+	if err != nil {
+		return err
+	}
+	_, links, err := s.backend.Traverse(ctx, id, &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to find links of: %v", id)
+	}
+	if len(links) > 0 && !cascade {
+		return errors.Wrapf(nodeHasLinks, "sst: cannot delete node: %v with %d links", id, len(links))
+	}
+	return s.update(ctx, func(tx BackendTx) error {
+		for _, link := range links {
+			a := s.association(link.SID)
+			if a == nil {
+				return errors.Wrapf(unknownAssociation, "sst: cannot delete link: %v", link.Key)
+			}
+			err := tx.RemoveLink(ctx, a.SemanticType, link.Key)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.RemoveNode(ctx, id)
+	})
+}
+
+// MustDeleteNode deletes the node if it exists, panics on error
+func (s *SST) MustDeleteNode(node *Node, cascade bool) {
+	err := s.DeleteNode(node, cascade)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// NodeCollections returns the names of the stored node collections
+func (s *SST) NodeCollections() ([]string, error) {
+	return s.NodeCollectionsContext(context.Background())
+}
+
+// NodeCollectionsContext returns the names of the stored node collections using the provided context
+func (s *SST) NodeCollectionsContext(ctx context.Context) ([]string, error) {
+	return s.backend.ReadNodeCollections(ctx)
+}
+
+// AddNodeCollection idempotently adds the named node collection to the
+// spacetime, so that nodes of that kind can be created and linked without
+// restarting with a changed Config.NodeCollections. If the spacetime has a
+// Schema, only configured node collections and node collections the Schema
+// declares or links by an association can be added, and the Schema is pushed to
+// the Backend again if Schema.Backend.
+func (s *SST) AddNodeCollection(name string) error {
+	return s.AddNodeCollectionContext(context.Background(), name)
+}
+
+// AddNodeCollectionContext invokes AddNodeCollection using the provided context
+func (s *SST) AddNodeCollectionContext(ctx context.Context, name string) error {
+	if !collectionRegex.MatchString(name) {
+		return errors.Wrapf(invalidNodeCollection, "sst: cannot add node collection: %q", name)
+	}
+	schema := s.config.Schema
+	if schema != nil && !schema.covers(name) && !contains(s.config.NodeCollections, name) {
+		return errors.Wrapf(schemaViolation, "sst: schema does not cover node collection: %v", name)
+	}
+	err := s.backend.AddNodeCollection(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to add node collection: %v", name)
+	}
+	if schema != nil && schema.Backend {
+		err = s.backend.ApplySchema(ctx, schema, s.Associations())
+		if err != nil {
+			return errors.Wrapf(err, "sst: failed to apply schema to node collection: %v", name)
+		}
+	}
+	return nil
+}
+
+// RemoveNodeCollection removes the named node collection from the spacetime if
+// it exists. A node collection holding nodes is not removed, its nodes must be
+// deleted first, see DeleteNode.
+func (s *SST) RemoveNodeCollection(name string) error {
+	return s.RemoveNodeCollectionContext(context.Background(), name)
+}
+
+// RemoveNodeCollectionContext invokes RemoveNodeCollection using the provided context
+func (s *SST) RemoveNodeCollectionContext(ctx context.Context, name string) error {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return err
+	}
+	if !contains(collections, name) {
+		return nil
+	}
+	nodes, err := s.backend.ListNodes(ctx, name, &NodeFilter{}, &Page{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		return errors.Wrapf(nodeCollectionNotEmpty, "sst: cannot remove node collection: %v", name)
+	}
+	err = s.backend.RemoveNodeCollection(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "sst: failed to remove node collection: %v", name)
+	}
+	return nil
+}
+
+// reconcileNodeCollections removes the stored node collections that are no
+// longer configured and hold no nodes, other than disconnectedCollection.
+// Configured node collections are added by Backend.Open. Node collections
+// holding nodes are kept so that their nodes are not lost to a configuration mistake.
+func (s *SST) reconcileNodeCollections(ctx context.Context) error {
+	collections, err := s.backend.ReadNodeCollections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range collections {
+		if name == disconnectedCollection || contains(s.config.NodeCollections, name) {
+			continue
+		}
+		err := s.RemoveNodeCollectionContext(ctx, name)
+		if err != nil && !IsNodeCollectionNotEmpty(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 // createNode idempotently creates node with the designated prefix
-func (s *SST) createNode(prefix string, key string, data map[string]interface{}, weight float64) (*Node, error) {
+func (s *SST) createNode(ctx context.Context, prefix string, key string, data map[string]interface{}, weight float64) (*Node, error) {
 	node := &Node{
 		Data:   data,
 		Key:    ToDocumentKey(key),
+		Name:   key,
 		Prefix: prefix,
 		Weight: weight,
 	}
-	err := s.insertNode(node)
+	err := s.insertNode(ctx, node)
 	if err != nil {
 		return nil, err
 	}
@@ -74,46 +305,86 @@ func (s *SST) createNode(prefix string, key string, data map[string]interface{},
 }
 
 // insertNode idempotently inserts the node into the collection specified by node.Prefix
-func (s *SST) insertNode(node *Node) error {
-	nodes, err := s.collectionOf(node.Prefix)
+func (s *SST) insertNode(ctx context.Context, node *Node) error {
+	err := s.validateNode(ctx, node)
 	if err != nil {
 		return err
 	}
-	exists, err := nodes.DocumentExists(context.TODO(), node.Key)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		_, err := nodes.CreateDocument(context.TODO(), node)
-		if err != nil {
-			return errors.Wrapf(err, "sst: failed to create node: %v", node)
-		}
-	} else {
-		if node.Data == nil && node.Weight == 0.0 {
-			return nil // Do not update the node if there is no data to enter
-		}
-		var existing Node
-		_, err := nodes.ReadDocument(context.TODO(), node.Key, &existing)
-		if err != nil {
-			return errors.Wrapf(err, "sst: failed to read node: %v", node.Key)
+	return s.backend.UpsertNode(ctx, node)
+}
+
+// selectNodes returns the page of the nodes selected by the filter
+func selectNodes(nodes []*Node, filter *NodeFilter, page *Page) []*Node {
+	selected := make([]*Node, 0)
+	for _, node := range nodes {
+		if filter.match(node) {
+			selected = append(selected, node)
 		}
-		if existing.Weight != node.Weight || !reflect.DeepEqual(existing.Data, node.Data) {
-			_, err := nodes.UpdateDocument(context.TODO(), node.Key, node)
-			if err != nil {
-				return errors.Wrapf(err, "sst: failed to update node: %v", node)
-			}
+	}
+	if page.Offset >= len(selected) {
+		return selected[:0]
+	}
+	selected = selected[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(selected) {
+		selected = selected[:page.Limit]
+	}
+	return selected
+}
+
+// match returns true if the filter selects the node
+func (f *NodeFilter) match(node *Node) bool {
+	name := node.Name
+	if name == "" {
+		name = node.Key
+	}
+	if !strings.HasPrefix(name, f.NamePrefix) {
+		return false
+	}
+	if f.MinWeight != 0 && node.Weight < f.MinWeight {
+		return false
+	}
+	for k, v := range f.Data {
+		stored, ok := node.Data[k]
+		if !ok || !jsonEqual(stored, v) {
+			return false
 		}
 	}
-	return nil
+	return true
+}
+
+// dataKeys returns the keys of the data filter, sorted
+func (f *NodeFilter) dataKeys() []string {
+	keys := make([]string, 0, len(f.Data))
+	for k := range f.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonEqual returns true if the values have the same JSON encoding
+func jsonEqual(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// splitNodeID splits node _id into node collection name and node key at the
+// first '/', node collection names containing none
+func splitNodeID(id string) (string, string) {
+	i := strings.Index(id, "/")
+	if i < 0 {
+		return ".", id
+	}
+	return id[:i], id[i+1:]
 }
 
-// collectionOf identifies node collection based on node prefix
-func (s *SST) collectionOf(prefix string) (arango.Collection, error) {
-	col := s.nodes[prefix[:len(prefix)-1]]
-	if col == nil {
-		return nil, errors.New(fmt.Sprintf("sst: no node collection for prefix: %v", prefix))
+// Label returns the name of the node, decoded from its key if it has no name
+func (n *Node) Label() string {
+	if n.Name != "" {
+		return n.Name
 	}
-	return col, nil
+	return FromDocumentKey(n.Key)
 }
 
 // NodeID returns the ArangoDB _id for a node
@@ -126,5 +397,5 @@ func NodeID(node *Node) (string, error) {
 
 // MustNodeID returns the ArangoDB _id for a node, panics on error
 func MustNodeID(node *Node) string {
-	return node.Prefix + node.Key
+	return node.Prefix + documentKey(node.Key)
 }
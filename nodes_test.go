@@ -0,0 +1,158 @@
+package sst
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testListNodes(t *testing.T, s *SST) {
+	s.MustCreateNode("Node", "Paris", map[string]interface{}{"country": "France", "tags": []interface{}{"capital"}}, 3)
+	s.MustCreateNode("Node", "Lyon", map[string]interface{}{"country": "France"}, 1)
+	s.MustCreateNode("Node", "Rome", map[string]interface{}{"country": "Italy"}, 2)
+	s.MustCreateNode("Node", "New York", nil, 2)
+
+	node, err := s.GetNode("Node/New York")
+	assert.NoError(t, err)
+	assert.Equal(t, &Node{Key: "New%20York", Name: "New York", Prefix: "Node/", Weight: 2}, node)
+	_, err = s.GetNode("Node/Berlin")
+	assert.True(t, IsNotFound(err))
+
+	names := func(nodes []*Node, err error) []string {
+		assert.NoError(t, err)
+		res := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			assert.Equal(t, "Node/", n.Prefix)
+			res = append(res, n.Name)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"Lyon", "New York", "Paris", "Rome"}, names(s.ListNodes("Node", nil, nil)))
+	assert.Equal(t, []string{"New York", "Paris"}, names(s.ListNodes("Node", nil, &Page{Offset: 1, Limit: 2})))
+	assert.Equal(t, []string{"Rome"}, names(s.ListNodes("Node", nil, &Page{Offset: 3})))
+	assert.Empty(t, names(s.ListNodes("Node", nil, &Page{Offset: 4})))
+	assert.Equal(t, []string{"Lyon", "Paris"}, names(s.ListNodes("Node", &NodeFilter{Data: map[string]interface{}{"country": "France"}}, nil)))
+	assert.Equal(t, []string{"Paris"}, names(s.ListNodes("Node", &NodeFilter{Data: map[string]interface{}{"tags": []string{"capital"}}}, nil)))
+	assert.Equal(t, []string{"New York", "Paris", "Rome"}, names(s.ListNodes("Node", &NodeFilter{MinWeight: 2}, nil)))
+	assert.Equal(t, []string{"New York"}, names(s.ListNodes("Node", &NodeFilter{NamePrefix: "New "}, nil)))
+	_, err = s.ListNodes("Missing", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestListNodes(t *testing.T) {
+	testListNodes(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testListNodes(t, s)
+}
+
+func testDeleteNode(t *testing.T, s *SST) {
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	rome := s.MustCreateNode("Node", "Rome", nil, 1)
+	s.MustCreateLink(france, "contains", paris, nil, 1)
+	s.MustBlockLink(paris, "related", rome, nil, 1)
+
+	err := s.DeleteNode(paris, false)
+	assert.Equal(t, nodeHasLinks, errors.Cause(err))
+	_, err = s.backend.ReadNode(context.TODO(), "Node/Paris")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.DeleteNode(paris, true))
+	_, err = s.backend.ReadNode(context.TODO(), "Node/Paris")
+	assert.True(t, IsNotFound(err))
+	nodes, links, err := s.Traverse(france, &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+	assert.Empty(t, links)
+	_, links, err = s.Traverse(rome, &TraversalOptions{Direction: Any, Depth: 1, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Empty(t, links)
+
+	assert.NoError(t, s.DeleteNode(rome, false))
+	assert.NoError(t, s.DeleteNode(rome, false))
+}
+
+func TestDeleteNode(t *testing.T) {
+	testDeleteNode(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testDeleteNode(t, s)
+}
+
+func TestNodeCollections(t *testing.T) {
+	s, err := NewSST(&Config{
+		Backend:         NewBoltBackend(filepath.Join(t.TempDir(), "sst.db")),
+		NodeCollections: []string{"Node", "Hub"},
+	})
+	assert.NoError(t, err)
+	defer s.Close()
+	collections, err := s.NodeCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hub", "Node"}, collections)
+}
+
+func testAddRemoveNodeCollection(t *testing.T, s *SST) {
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	_, err := s.CreateNode("Vehicle", "Bus", nil, 1)
+	assert.Error(t, err)
+
+	assert.NoError(t, s.AddNodeCollection("Vehicle"))
+	assert.NoError(t, s.AddNodeCollection("Vehicle"))
+	assert.Equal(t, invalidNodeCollection, errors.Cause(s.AddNodeCollection("Vehicle/Bus")))
+	collections, err := s.NodeCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Node", "Vehicle"}, collections)
+	bus, err := s.CreateNode("Vehicle", "Bus", nil, 1)
+	assert.NoError(t, err)
+	s.MustCreateLink(bus, "related", paris, nil, 1)
+
+	assert.True(t, IsNodeCollectionNotEmpty(s.RemoveNodeCollection("Vehicle")))
+	assert.NoError(t, s.DeleteNode(bus, true))
+	assert.NoError(t, s.RemoveNodeCollection("Vehicle"))
+	assert.NoError(t, s.RemoveNodeCollection("Vehicle"))
+	collections, err = s.NodeCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Node"}, collections)
+	_, err = s.CreateNode("Vehicle", "Bus", nil, 1)
+	assert.Error(t, err)
+}
+
+func TestAddRemoveNodeCollection(t *testing.T) {
+	testAddRemoveNodeCollection(t, memorySST(t))
+	s := boltSST(t, filepath.Join(t.TempDir(), "sst.db"))
+	defer s.Close()
+	testAddRemoveNodeCollection(t, s)
+}
+
+func TestReconcileNodeCollections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sst.db")
+	open := func(reconcile bool, collections ...string) *SST {
+		s, err := NewSST(&Config{Backend: NewBoltBackend(path), NodeCollections: collections, ReconcileNodeCollections: reconcile})
+		if err != nil {
+			t.Fatalf("failed to create SST: %v", err)
+		}
+		return s
+	}
+	s := open(false, "Node", "Hub", disconnectedCollection)
+	s.MustCreateNode("Hub", "Paris", nil, 1)
+	assert.NoError(t, s.Close())
+
+	s = open(false, "Vehicle")
+	collections, err := s.NodeCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{disconnectedCollection, "Hub", "Node", "Vehicle"}, collections)
+	s.MustCreateNode("Vehicle", "Bus", nil, 1)
+	assert.NoError(t, s.Close())
+
+	s = open(true, "Vehicle")
+	defer s.Close()
+	collections, err = s.NodeCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{disconnectedCollection, "Hub", "Vehicle"}, collections)
+	_, err = s.GetNode("Hub/Paris")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,333 @@
+package sst
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	negativeLinkWeight = errors.New("sst: negative link weight")
+)
+
+// PathOptions designate the paths found between two nodes
+type PathOptions struct {
+	// K is the maximum number of paths to find, shortest first. Defaults to 1.
+	K int
+	// Direction to walk links in
+	Direction Direction
+	// SemanticTypes of links to walk, all if none are designated. A negative
+	// SemanticType walks links of its positive counterpart in the opposite direction.
+	SemanticTypes []SemanticType
+	// Associations restricts walked links to those with the designated association
+	// keys, all associations are walked if none are designated.
+	Associations []string
+	// Weighted uses Link.Weight as the cost of walking a link, as ArangoDB
+	// weighted traversals do. Each link costs 1 otherwise.
+	Weighted bool
+}
+
+// pathOptions returns the options, or the defaults if nil
+func pathOptions(opts *PathOptions) *PathOptions {
+	if opts == nil {
+		return &PathOptions{K: 1, Direction: Any}
+	}
+	return opts
+}
+
+// Path is a sequence of hops between two nodes
+type Path struct {
+	// Hops are the links walked in order
+	Hops []*Hop
+	// Cost is the sum of the costs of walking the links of the path
+	Cost float64
+}
+
+// Hop is a link walked on a path
+type Hop struct {
+	// From is the node the hop starts at, it is Link.To if the link was walked backward
+	From *Node
+	// Link is the walked link
+	Link *Link
+	// To is the node the hop ends at, it is Link.From if the link was walked backward
+	To *Node
+	// Forward is true if the link was walked from Link.From to Link.To
+	Forward bool
+	// Phrase is the Association.Fwd phrase if the link was walked forward,
+	// Association.Bwd phrase otherwise
+	Phrase string
+}
+
+// Paths returns up to opts.K shortest loopless paths from one node to another,
+// shortest first. Negated links are never walked. If opts is nil, the shortest
+// path walking links in any direction is returned.
+func (s *SST) Paths(from, to *Node, opts *PathOptions) ([]*Path, error) {
+	return s.PathsContext(context.Background(), from, to, opts)
+}
+
+// PathsContext invokes Paths using the provided context
+func (s *SST) PathsContext(ctx context.Context, from, to *Node, opts *PathOptions) ([]*Path, error) {
+	fromID, err := NodeID(from)
+	if err != nil {
+		return nil, err
+	}
+	toID, err := NodeID(to)
+	if err != nil {
+		return nil, err
+	}
+	opts = pathOptions(opts)
+	k := opts.K
+	if k < 1 {
+		k = 1
+	}
+	g := &pathGraph{
+		ctx:   ctx,
+		s:     s,
+		opts:  opts,
+		nodes: map[string]*Node{fromID: from, toID: to},
+		edges: make(map[string][]*pathEdge),
+	}
+	if len(opts.Associations) > 0 {
+		g.associations = make(map[string]bool)
+		for _, a := range opts.Associations {
+			g.associations[identifierKey(a)] = true
+		}
+	}
+
+	// Yen's k shortest loopless paths
+	first, err := g.shortest(fromID, toID, nil, nil)
+	if err != nil || first == nil {
+		return []*Path{}, err
+	}
+	found := []*pathCandidate{first}
+	candidates := make([]*pathCandidate, 0)
+	for len(found) < k {
+		prev := found[len(found)-1]
+		for i := 0; i < len(prev.edges); i++ {
+			spurID := prev.nodeID(fromID, i)
+			root := prev.edges[:i]
+			removedEdges := make(map[string]bool)
+			for _, p := range found {
+				if len(p.edges) > i && sameEdges(p.edges[:i], root) {
+					removedEdges[p.edges[i].link.Key] = true
+				}
+			}
+			removedNodes := make(map[string]bool)
+			for j := 0; j < i; j++ {
+				removedNodes[prev.nodeID(fromID, j)] = true
+			}
+			spur, err := g.shortest(spurID, toID, removedNodes, removedEdges)
+			if err != nil {
+				return nil, err
+			}
+			if spur == nil {
+				continue
+			}
+			candidate := &pathCandidate{edges: append(append([]*pathEdge{}, root...), spur.edges...)}
+			for _, e := range candidate.edges {
+				candidate.cost += e.cost
+			}
+			if !containsPath(found, candidate) && !containsPath(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].cost < candidates[j].cost
+		})
+		found = append(found, candidates[0])
+		candidates = candidates[1:]
+	}
+
+	paths := make([]*Path, 0, len(found))
+	for _, p := range found {
+		paths = append(paths, g.path(fromID, p))
+	}
+	return paths, nil
+}
+
+// pathEdge is a link as walked from one node to another
+type pathEdge struct {
+	link    *Link
+	toID    string
+	forward bool
+	cost    float64
+}
+
+// pathCandidate is a path being considered by Paths
+type pathCandidate struct {
+	edges []*pathEdge
+	cost  float64
+}
+
+// nodeID returns the _id of the node the i-th edge of the path starts at
+func (p *pathCandidate) nodeID(startID string, i int) string {
+	if i == 0 {
+		return startID
+	}
+	return p.edges[i-1].toID
+}
+
+// pathGraph lazily loads the links walked by Paths
+type pathGraph struct {
+	ctx          context.Context
+	s            *SST
+	opts         *PathOptions
+	associations map[string]bool
+	nodes        map[string]*Node
+	edges        map[string][]*pathEdge
+}
+
+// adjacent returns the edges walkable from the node with the designated _id
+func (g *pathGraph) adjacent(id string) ([]*pathEdge, error) {
+	if edges, ok := g.edges[id]; ok {
+		return edges, nil
+	}
+	nodes, links, err := g.s.backend.Traverse(g.ctx, id, &TraversalOptions{
+		SemanticTypes: g.opts.SemanticTypes,
+		Direction:     g.opts.Direction,
+		Depth:         1,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to find links of: %v", id)
+	}
+	for _, node := range nodes {
+		g.nodes[MustNodeID(node)] = node
+	}
+	edges := make([]*pathEdge, 0, len(links))
+	for _, link := range links {
+		if link.From == link.To {
+			continue
+		}
+		if g.associations != nil && !g.associations[link.SID] {
+			continue
+		}
+		edge := &pathEdge{link: link, toID: link.To, forward: true, cost: 1}
+		if link.To == id {
+			edge.toID = link.From
+			edge.forward = false
+		}
+		if g.nodes[edge.toID] == nil {
+			continue // dangling link
+		}
+		if g.opts.Weighted {
+			if link.Weight < 0 {
+				return nil, errors.Wrapf(negativeLinkWeight, "sst: cannot find weighted paths over link: %v", link.Key)
+			}
+			edge.cost = link.Weight
+		}
+		edges = append(edges, edge)
+	}
+	g.edges[id] = edges
+	return edges, nil
+}
+
+// shortest finds the shortest path using Dijkstra's algorithm while avoiding
+// designated nodes and links. Returns nil if there is no path.
+func (g *pathGraph) shortest(fromID, toID string, removedNodes, removedEdges map[string]bool) (*pathCandidate, error) {
+	dist := map[string]float64{fromID: 0}
+	prev := make(map[string]*pathEdge)
+	prevID := make(map[string]string)
+	done := make(map[string]bool)
+	queue := &pathQueue{{id: fromID}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(pathQueueItem)
+		if done[item.id] {
+			continue
+		}
+		done[item.id] = true
+		if item.id == toID {
+			break
+		}
+		edges, err := g.adjacent(item.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			if removedNodes[e.toID] || removedEdges[e.link.Key] || done[e.toID] {
+				continue
+			}
+			d := dist[item.id] + e.cost
+			if existing, ok := dist[e.toID]; !ok || d < existing {
+				dist[e.toID] = d
+				prev[e.toID] = e
+				prevID[e.toID] = item.id
+				heap.Push(queue, pathQueueItem{id: e.toID, dist: d})
+			}
+		}
+	}
+	if !done[toID] {
+		return nil, nil
+	}
+	p := &pathCandidate{cost: dist[toID]}
+	for id := toID; id != fromID; id = prevID[id] {
+		p.edges = append([]*pathEdge{prev[id]}, p.edges...)
+	}
+	return p, nil
+}
+
+// path renders the candidate path starting at the designated node
+func (g *pathGraph) path(startID string, p *pathCandidate) *Path {
+	path := &Path{Hops: make([]*Hop, 0, len(p.edges)), Cost: p.cost}
+	for i, e := range p.edges {
+		hop := &Hop{
+			From:    g.nodes[p.nodeID(startID, i)],
+			Link:    e.link,
+			To:      g.nodes[e.toID],
+			Forward: e.forward,
+			Phrase:  e.link.SID,
+		}
+		if a := g.s.association(e.link.SID); a != nil {
+			hop.Phrase = a.Phrase(e.forward, false)
+		}
+		path.Hops = append(path.Hops, hop)
+	}
+	return path
+}
+
+// sameEdges returns true if both edge sequences walk the same links
+func sameEdges(a, b []*pathEdge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].link.Key != b[i].link.Key || a[i].toID != b[i].toID {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPath returns true if paths contain a path walking the same links as p
+func containsPath(paths []*pathCandidate, p *pathCandidate) bool {
+	for _, existing := range paths {
+		if sameEdges(existing.edges, p.edges) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathQueueItem is a node queued by Dijkstra's algorithm
+type pathQueueItem struct {
+	id   string
+	dist float64
+}
+
+// pathQueue is a min-heap of queued nodes ordered by distance
+type pathQueue []pathQueueItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathQueueItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,57 @@
+package sst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaths(t *testing.T) {
+	s := memorySST(t)
+	person := s.MustCreateNode("Node", "Emily", nil, 1)
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	visa := s.MustCreateNode("Node", "Schengen visa", nil, 1)
+	s.MustCreateLink(person, "coactive", paris, nil, 1)
+	s.MustCreateLink(paris, "part_of", france, nil, 1)
+	s.MustCreateLink(person, "expresses", visa, nil, 5)
+	s.MustCreateLink(france, "has_role", visa, nil, 5)
+
+	paths, err := s.Paths(person, france, &PathOptions{K: 3, Direction: Any})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 2)
+	assert.Equal(t, 2.0, paths[0].Cost)
+	assert.Equal(t, person, paths[0].Hops[0].From)
+	assert.Equal(t, "occurred together with", paths[0].Hops[0].Phrase)
+	assert.Equal(t, paris, paths[0].Hops[0].To)
+	assert.Equal(t, "is part of", paths[0].Hops[1].Phrase)
+	assert.True(t, paths[0].Hops[1].Forward)
+	assert.Equal(t, visa, paths[1].Hops[0].To)
+	assert.Equal(t, "is a role fulfilled by", paths[1].Hops[1].Phrase)
+	assert.False(t, paths[1].Hops[1].Forward)
+
+	paths, err = s.Paths(person, france, nil)
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1)
+	assert.Equal(t, paris, paths[0].Hops[0].To)
+
+	paths, err = s.Paths(person, france, &PathOptions{Direction: Outbound})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1)
+
+	paths, err = s.Paths(person, france, &PathOptions{K: 2, Direction: Any, Associations: []string{"expresses", "has_role"}})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1)
+	assert.Equal(t, visa, paths[0].Hops[0].To)
+
+	paths, err = s.Paths(person, france, &PathOptions{Direction: Any, SemanticTypes: []SemanticType{Expresses}, Weighted: true})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1)
+	assert.Equal(t, 10.0, paths[0].Cost)
+
+	s.MustBlockLink(paris, "part_of", france, nil, 1)
+	s.MustDeleteLink(paris, "part_of", france, false)
+	paths, err = s.Paths(person, france, &PathOptions{SemanticTypes: []SemanticType{Near, Contains}, Direction: Any})
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/arangodb/go-driver"
 )
 
-// Query executes the designated ArangoDB query
+// Query executes the designated Backend query, AQL for the ArangoDB backend
 func (s *SST) Query(ctx context.Context, query string, vars map[string]interface{}) (driver.Cursor, error) {
-	return s.db.Query(ctx, query, vars)
+	return s.backend.Query(ctx, query, vars)
 }
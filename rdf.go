@@ -0,0 +1,676 @@
+package sst
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	invalidTurtle = errors.New("sst: invalid Turtle")
+)
+
+const (
+	rdfNamespace  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfsNamespace = "http://www.w3.org/2000/01/rdf-schema#"
+	xsdNamespace  = "http://www.w3.org/2001/XMLSchema#"
+	// SSTNamespace is the namespace of the RDF vocabulary of Semantic Spacetime
+	SSTNamespace = "https://github.com/tristanls/sst#"
+)
+
+// rdfSemanticTypes are the SemanticTypes declared as properties of the vocabulary
+var rdfSemanticTypes = []SemanticType{Near, Follows, -Follows, Contains, -Contains, Expresses, -Expresses}
+
+// rdfTerm is an IRI, a blank node, designated by a "_:" prefixed IRI, or a literal
+type rdfTerm struct {
+	value    string
+	datatype string
+	literal  bool
+}
+
+// rdfTriple is an RDF statement
+type rdfTriple struct {
+	subject, predicate string
+	object             rdfTerm
+}
+
+// WriteTurtle writes the whole spacetime as RDF in Turtle format.
+//
+// Nodes are identified by the IRI base + "node/" + _id and associations by the
+// IRI base + "association/" + key. Each association is a property labelled with
+// its phrases and is a sub-property of the property of its SemanticType in the
+// vocabulary designated by SSTNamespace, such as sst:Contains. Positive links
+// are asserted as statements. Each link, positive or negated, is reified as an
+// rdf:Statement identified by base + "link/" + key and annotated with the key,
+// weight, data and time of the link. Negated links are not asserted, their
+// reified statement is an sst:NegativeStatement instead. Node and link data are
+// rdf:JSON literals. Timelines are not exported.
+func (s *SST) WriteTurtle(w io.Writer, base string) error {
+	return s.WriteTurtleContext(context.Background(), w, base)
+}
+
+// WriteTurtleContext invokes WriteTurtle using the provided context
+func (s *SST) WriteTurtleContext(ctx context.Context, w io.Writer, base string) error {
+	g, err := s.ExportGraphContext(ctx)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "@prefix rdf: <%s> .\n", rdfNamespace)
+	fmt.Fprintf(bw, "@prefix rdfs: <%s> .\n", rdfsNamespace)
+	fmt.Fprintf(bw, "@prefix xsd: <%s> .\n", xsdNamespace)
+	fmt.Fprintf(bw, "@prefix sst: <%s> .\n", SSTNamespace)
+	fmt.Fprintln(bw)
+	for _, typ := range rdfSemanticTypes {
+		fmt.Fprintf(bw, "sst:%s a rdf:Property ;\n\trdfs:label %s .\n", typ, turtleString(typ.String()))
+	}
+
+	for _, a := range g.Associations {
+		fmt.Fprintln(bw)
+		fmt.Fprintf(bw, "%s a rdf:Property ;\n", turtleIRI(base+"association/"+url.PathEscape(a.Key)))
+		fmt.Fprintf(bw, "\trdfs:subPropertyOf sst:%s ;\n", a.SemanticType)
+		fmt.Fprintf(bw, "\tsst:key %s ;\n", turtleString(a.Key))
+		fmt.Fprintf(bw, "\trdfs:label %s ;\n", turtleString(a.Fwd))
+		fmt.Fprintf(bw, "\tsst:backwardLabel %s ;\n", turtleString(a.Bwd))
+		fmt.Fprintf(bw, "\tsst:negatedLabel %s ;\n", turtleString(a.Nfwd))
+		fmt.Fprintf(bw, "\tsst:negatedBackwardLabel %s .\n", turtleString(a.Nbwd))
+	}
+
+	for _, n := range g.Nodes {
+		fmt.Fprintln(bw)
+		fmt.Fprintf(bw, "%s a sst:Node ;\n", turtleIRI(rdfNodeIRI(base, n.Collection+"/"+n.Key)))
+		fmt.Fprintf(bw, "\trdfs:label %s ;\n", turtleString(n.Label()))
+		if n.Name != "" {
+			fmt.Fprintf(bw, "\tsst:name %s ;\n", turtleString(n.Name))
+		}
+		fmt.Fprintf(bw, "\tsst:collection %s ;\n", turtleString(n.Collection))
+		fmt.Fprintf(bw, "\tsst:key %s", turtleString(n.Key))
+		err := writeTurtleAnnotations(bw, n.Weight, n.Data, n.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, l := range g.Links {
+		from, to := turtleIRI(rdfNodeIRI(base, l.From)), turtleIRI(rdfNodeIRI(base, l.To))
+		predicate := turtleIRI(base + "association/" + url.PathEscape(l.Association))
+		fmt.Fprintln(bw)
+		if !l.Negated {
+			fmt.Fprintf(bw, "%s %s %s .\n", from, predicate, to)
+		}
+		types := "rdf:Statement"
+		if l.Negated {
+			types += ", sst:NegativeStatement"
+		}
+		fmt.Fprintf(bw, "%s a %s ;\n", turtleIRI(base+"link/"+url.PathEscape(l.Key)), types)
+		fmt.Fprintf(bw, "\trdf:subject %s ;\n", from)
+		fmt.Fprintf(bw, "\trdf:predicate %s ;\n", predicate)
+		fmt.Fprintf(bw, "\trdf:object %s ;\n", to)
+		fmt.Fprintf(bw, "\tsst:key %s", turtleString(l.Key))
+		err := writeTurtleAnnotations(bw, l.Weight, l.Data, l.Time)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ImportTurtle imports RDF in Turtle format, see ImportGraph. Nodes and
+// associations are identified by IRIs relative to base as written by
+// WriteTurtle. Asserted statements between nodes whose predicate is an
+// association are imported as links of weight 1 unless reified with annotations.
+//
+// Only a subset of Turtle is supported: prefix declarations, IRIs, prefixed
+// names, blank node labels, string, numeric and boolean literals, and predicate
+// and object lists. Relative IRIs, anonymous blank nodes, collections and
+// multi-line strings are not supported.
+func (s *SST) ImportTurtle(r io.Reader, base string) error {
+	return s.ImportTurtleContext(context.Background(), r, base)
+}
+
+// ImportTurtleContext invokes ImportTurtle using the provided context
+func (s *SST) ImportTurtleContext(ctx context.Context, r io.Reader, base string) error {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	triples, err := parseTurtle(string(src))
+	if err != nil {
+		return err
+	}
+	g, err := rdfGraph(triples, base)
+	if err != nil {
+		return err
+	}
+	return s.ImportGraphContext(ctx, g)
+}
+
+// rdfGraph interprets the triples written by WriteTurtle as a Graph
+func rdfGraph(triples []rdfTriple, base string) (*Graph, error) {
+	subjects := make([]string, 0)
+	properties := make(map[string]map[string][]rdfTerm)
+	for _, t := range triples {
+		if properties[t.subject] == nil {
+			subjects = append(subjects, t.subject)
+			properties[t.subject] = make(map[string][]rdfTerm)
+		}
+		properties[t.subject][t.predicate] = append(properties[t.subject][t.predicate], t.object)
+	}
+	first := func(subject, predicate string) string {
+		if objects := properties[subject][predicate]; len(objects) > 0 {
+			return objects[0].value
+		}
+		return ""
+	}
+	isA := func(subject, class string) bool {
+		for _, o := range properties[subject][rdfNamespace+"type"] {
+			if !o.literal && o.value == class {
+				return true
+			}
+		}
+		return false
+	}
+
+	g := &Graph{}
+	associations := make(map[string]string)
+	for _, subject := range subjects {
+		super := first(subject, rdfsNamespace+"subPropertyOf")
+		if !isA(subject, rdfNamespace+"Property") || !strings.HasPrefix(super, SSTNamespace) {
+			continue
+		}
+		typ, ok := rdfSemanticType(strings.TrimPrefix(super, SSTNamespace))
+		if !ok {
+			return nil, errors.Wrapf(invalidTurtle, "sst: unknown semantic type: %v", super)
+		}
+		key := first(subject, SSTNamespace+"key")
+		if key == "" {
+			key = rdfLocalName(subject, base+"association/")
+		}
+		associations[subject] = key
+		g.Associations = append(g.Associations, &Association{
+			Key:          key,
+			SemanticType: typ,
+			Fwd:          first(subject, rdfsNamespace+"label"),
+			Bwd:          first(subject, SSTNamespace+"backwardLabel"),
+			Nfwd:         first(subject, SSTNamespace+"negatedLabel"),
+			Nbwd:         first(subject, SSTNamespace+"negatedBackwardLabel"),
+		})
+	}
+
+	collections := make(map[string]bool)
+	nodeIDs := make(map[string]string)
+	for _, subject := range subjects {
+		if !isA(subject, SSTNamespace+"Node") {
+			continue
+		}
+		kind, key := splitNodeID(rdfLocalName(subject, base+"node/"))
+		if c := first(subject, SSTNamespace+"collection"); c != "" {
+			kind = c
+		}
+		if k := first(subject, SSTNamespace+"key"); k != "" {
+			key = k
+		}
+		node := &GraphNode{Collection: kind, Key: key, Name: first(subject, SSTNamespace+"name")}
+		var err error
+		node.Weight, node.Data, node.Time, err = rdfAnnotations(properties[subject])
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to import node: %v", subject)
+		}
+		collections[kind] = true
+		nodeIDs[subject] = kind + "/" + key
+		g.Nodes = append(g.Nodes, node)
+	}
+	nodeID := func(iri string) string {
+		if id, ok := nodeIDs[iri]; ok {
+			return id
+		}
+		return rdfLocalName(iri, base+"node/")
+	}
+
+	reified := make(map[rdfTriple]bool)
+	for _, subject := range subjects {
+		if !isA(subject, rdfNamespace+"Statement") {
+			continue
+		}
+		predicate := first(subject, rdfNamespace+"predicate")
+		key, ok := associations[predicate]
+		if !ok {
+			key = rdfLocalName(predicate, base+"association/")
+		}
+		from, to := first(subject, rdfNamespace+"subject"), first(subject, rdfNamespace+"object")
+		negated := isA(subject, SSTNamespace+"NegativeStatement")
+		if !negated {
+			reified[rdfTriple{subject: from, predicate: predicate, object: rdfTerm{value: to}}] = true
+		}
+		link := &GraphLink{Key: first(subject, SSTNamespace+"key"), From: nodeID(from), To: nodeID(to), Association: key, Negated: negated}
+		var err error
+		link.Weight, link.Data, link.Time, err = rdfAnnotations(properties[subject])
+		if err != nil {
+			return nil, errors.Wrapf(err, "sst: failed to import link: %v", subject)
+		}
+		g.Links = append(g.Links, link)
+	}
+	for _, t := range triples {
+		key, ok := associations[t.predicate]
+		if !ok || t.object.literal || reified[t] {
+			continue
+		}
+		g.Links = append(g.Links, &GraphLink{From: nodeID(t.subject), To: nodeID(t.object.value), Association: key, Weight: 1})
+	}
+
+	for kind := range collections {
+		g.Collections = append(g.Collections, kind)
+	}
+	sort.Strings(g.Collections)
+	return g, nil
+}
+
+// rdfAnnotations reads the weight, data and time annotations
+func rdfAnnotations(properties map[string][]rdfTerm) (float64, map[string]interface{}, *time.Time, error) {
+	var weight float64
+	if objects := properties[SSTNamespace+"weight"]; len(objects) > 0 {
+		var err error
+		weight, err = strconv.ParseFloat(objects[0].value, 64)
+		if err != nil {
+			return 0, nil, nil, errors.Wrapf(invalidTurtle, "sst: invalid weight: %v", objects[0].value)
+		}
+	}
+	var data map[string]interface{}
+	if objects := properties[SSTNamespace+"data"]; len(objects) > 0 {
+		err := json.Unmarshal([]byte(objects[0].value), &data)
+		if err != nil {
+			return 0, nil, nil, errors.Wrapf(invalidTurtle, "sst: invalid data: %v", err)
+		}
+	}
+	var t *time.Time
+	if objects := properties[SSTNamespace+"time"]; len(objects) > 0 {
+		parsed, err := time.Parse(time.RFC3339Nano, objects[0].value)
+		if err != nil {
+			return 0, nil, nil, errors.Wrapf(invalidTurtle, "sst: invalid time: %v", objects[0].value)
+		}
+		t = &parsed
+	}
+	return weight, data, t, nil
+}
+
+// rdfSemanticType returns the SemanticType with the designated name
+func rdfSemanticType(name string) (SemanticType, bool) {
+	for _, typ := range rdfSemanticTypes {
+		if typ.String() == name {
+			return typ, true
+		}
+	}
+	return 0, false
+}
+
+// rdfNodeIRI returns the IRI of the node with the designated _id
+func rdfNodeIRI(base, id string) string {
+	kind, key := splitNodeID(id)
+	return base + "node/" + url.PathEscape(kind) + "/" + url.PathEscape(key)
+}
+
+// rdfLocalName returns the unescaped IRI relative to the namespace
+func rdfLocalName(iri, namespace string) string {
+	local := strings.TrimPrefix(iri, namespace)
+	if unescaped, err := url.PathUnescape(local); err == nil {
+		return unescaped
+	}
+	return local
+}
+
+// writeTurtleAnnotations completes the description of a node or link with the
+// weight, data and time annotations
+func writeTurtleAnnotations(w io.Writer, weight float64, data map[string]interface{}, t *time.Time) error {
+	fmt.Fprintf(w, " ;\n\tsst:weight \"%s\"^^xsd:double", strconv.FormatFloat(weight, 'g', -1, 64))
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "sst: failed to encode data")
+		}
+		fmt.Fprintf(w, " ;\n\tsst:data %s^^rdf:JSON", turtleString(string(encoded)))
+	}
+	if t != nil {
+		fmt.Fprintf(w, " ;\n\tsst:time \"%s\"^^xsd:dateTime", t.Format(time.RFC3339Nano))
+	}
+	_, err := fmt.Fprintln(w, " .")
+	return err
+}
+
+// turtleIRI writes the IRI as a Turtle IRI reference
+func turtleIRI(iri string) string {
+	return "<" + strings.NewReplacer(">", "%3E", "<", "%3C", `"`, "%22", " ", "%20", `\`, "%5C").Replace(iri) + ">"
+}
+
+// turtleString writes the string as a Turtle string literal
+func turtleString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s) + `"`
+}
+
+// turtleParser parses the supported subset of Turtle, see ImportTurtle
+type turtleParser struct {
+	src      string
+	pos      int
+	prefixes map[string]string
+	triples  []rdfTriple
+}
+
+// parseTurtle parses the Turtle document into triples
+func parseTurtle(src string) ([]rdfTriple, error) {
+	p := &turtleParser{src: src, prefixes: make(map[string]string)}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.src) {
+			return p.triples, nil
+		}
+		var err error
+		switch {
+		case p.consumeKeyword("@prefix"):
+			err = p.prefix(true)
+		case p.consumeKeyword("PREFIX"):
+			err = p.prefix(false)
+		default:
+			err = p.statement()
+		}
+		if err != nil {
+			line := strings.Count(p.src[:p.pos], "\n") + 1
+			return nil, errors.Wrapf(err, "sst: failed to parse Turtle at line %d", line)
+		}
+	}
+}
+
+// prefix parses a prefix declaration
+func (p *turtleParser) prefix(dot bool) error {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != ':' && !isTurtleSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	if !p.consume(":") {
+		return errors.Wrap(invalidTurtle, "sst: expected prefix name")
+	}
+	name := p.src[start : p.pos-1]
+	p.skipSpace()
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.prefixes[name] = iri
+	if dot {
+		p.skipSpace()
+		if !p.consume(".") {
+			return errors.Wrap(invalidTurtle, "sst: expected '.' after prefix declaration")
+		}
+	}
+	return nil
+}
+
+// statement parses the triples of a subject
+func (p *turtleParser) statement() error {
+	subject, err := p.term()
+	if err != nil {
+		return err
+	}
+	if subject.literal {
+		return errors.Wrap(invalidTurtle, "sst: literal subject")
+	}
+	for {
+		p.skipSpace()
+		if p.consume(".") {
+			return nil
+		}
+		var predicate rdfTerm
+		if p.consumeKeyword("a") {
+			predicate = rdfTerm{value: rdfNamespace + "type"}
+		} else if predicate, err = p.term(); err != nil {
+			return err
+		}
+		for {
+			object, err := p.term()
+			if err != nil {
+				return err
+			}
+			p.triples = append(p.triples, rdfTriple{subject: subject.value, predicate: predicate.value, object: object})
+			p.skipSpace()
+			if !p.consume(",") {
+				break
+			}
+		}
+		p.skipSpace()
+		if p.consume(".") {
+			return nil
+		}
+		if !p.consume(";") {
+			return errors.Wrap(invalidTurtle, "sst: expected ',', ';' or '.'")
+		}
+		for p.skipSpace(); p.consume(";"); p.skipSpace() {
+		}
+		if p.consume(".") {
+			return nil
+		}
+	}
+}
+
+// term parses an IRI, prefixed name, blank node label or literal
+func (p *turtleParser) term() (rdfTerm, error) {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: unexpected end of document")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '<':
+		iri, err := p.iriRef()
+		return rdfTerm{value: iri}, err
+	case c == '"' || c == '\'':
+		return p.literal()
+	case c == '[' || c == '(':
+		return rdfTerm{}, errors.Wrapf(invalidTurtle, "sst: unsupported syntax: %q", c)
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isTurtleNameChar(p.src[p.pos]) {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+	// A prefixed name does not end with a '.'
+	for p.pos > start && p.src[p.pos-1] == '.' {
+		p.pos--
+	}
+	name := p.src[start:p.pos]
+	switch name {
+	case "true", "false":
+		return rdfTerm{value: name, datatype: xsdNamespace + "boolean", literal: true}, nil
+	}
+	if strings.HasPrefix(name, "_:") {
+		return rdfTerm{value: name}, nil
+	}
+	i := strings.Index(name, ":")
+	if i < 0 {
+		return rdfTerm{}, errors.Wrapf(invalidTurtle, "sst: unexpected token: %q", name)
+	}
+	namespace, ok := p.prefixes[name[:i]]
+	if !ok {
+		return rdfTerm{}, errors.Wrapf(invalidTurtle, "sst: undeclared prefix: %q", name[:i])
+	}
+	local := strings.NewReplacer(`\`, "").Replace(name[i+1:])
+	return rdfTerm{value: namespace + local}, nil
+}
+
+// iriRef parses an IRI reference
+func (p *turtleParser) iriRef() (string, error) {
+	if !p.consume("<") {
+		return "", errors.Wrap(invalidTurtle, "sst: expected IRI")
+	}
+	end := strings.IndexByte(p.src[p.pos:], '>')
+	if end < 0 {
+		return "", errors.Wrap(invalidTurtle, "sst: unterminated IRI")
+	}
+	iri := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	if !strings.Contains(iri, ":") {
+		return "", errors.Wrapf(invalidTurtle, "sst: unsupported relative IRI: %q", iri)
+	}
+	return iri, nil
+}
+
+// literal parses a string literal with an optional datatype or language tag
+func (p *turtleParser) literal() (rdfTerm, error) {
+	quote := p.src[p.pos]
+	if strings.HasPrefix(p.src[p.pos:], strings.Repeat(string(quote), 3)) {
+		return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: unsupported multi-line string")
+	}
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: unterminated string")
+		}
+		c := p.src[p.pos]
+		if c == quote {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			b.WriteRune(r)
+			p.pos += size
+			continue
+		}
+		if p.pos+1 >= len(p.src) {
+			return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: unterminated string")
+		}
+		escaped := p.src[p.pos+1]
+		p.pos += 2
+		switch escaped {
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\'', '\\':
+			b.WriteByte(escaped)
+		case 'u', 'U':
+			size := 4
+			if escaped == 'U' {
+				size = 8
+			}
+			if p.pos+size > len(p.src) {
+				return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: invalid escape sequence")
+			}
+			code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+			if err != nil {
+				return rdfTerm{}, errors.Wrap(invalidTurtle, "sst: invalid escape sequence")
+			}
+			b.WriteRune(rune(code))
+			p.pos += size
+		default:
+			return rdfTerm{}, errors.Wrapf(invalidTurtle, "sst: invalid escape sequence: \\%c", escaped)
+		}
+	}
+	term := rdfTerm{value: b.String(), datatype: xsdNamespace + "string", literal: true}
+	switch {
+	case p.consume("^^"):
+		datatype, err := p.term()
+		if err != nil {
+			return rdfTerm{}, err
+		}
+		term.datatype = datatype.value
+	case p.consume("@"):
+		for p.pos < len(p.src) && (isTurtleLetter(p.src[p.pos]) || p.src[p.pos] == '-') {
+			p.pos++
+		}
+	}
+	return term, nil
+}
+
+// number parses an integer, decimal or double literal
+func (p *turtleParser) number() (rdfTerm, error) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("+-.0123456789eE", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	// A number does not end with a '.'
+	for p.pos > start && p.src[p.pos-1] == '.' {
+		p.pos--
+	}
+	value := p.src[start:p.pos]
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return rdfTerm{}, errors.Wrapf(invalidTurtle, "sst: invalid number: %q", value)
+	}
+	datatype := "integer"
+	if strings.ContainsAny(value, "eE") {
+		datatype = "double"
+	} else if strings.Contains(value, ".") {
+		datatype = "decimal"
+	}
+	return rdfTerm{value: value, datatype: xsdNamespace + datatype, literal: true}, nil
+}
+
+// skipSpace skips white space and comments
+func (p *turtleParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case isTurtleSpace(p.src[p.pos]):
+			p.pos++
+		case p.src[p.pos] == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// consume skips the token if it is next
+func (p *turtleParser) consume(token string) bool {
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// consumeKeyword skips the keyword if it is next and followed by white space
+func (p *turtleParser) consumeKeyword(keyword string) bool {
+	end := p.pos + len(keyword)
+	if !strings.HasPrefix(p.src[p.pos:], keyword) || (end < len(p.src) && !isTurtleSpace(p.src[end])) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func isTurtleSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isTurtleLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isTurtleNameChar(c byte) bool {
+	return c >= utf8.RuneSelf || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("_-.:%\\", c) >= 0
+}
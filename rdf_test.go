@@ -0,0 +1,74 @@
+package sst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTurtleRoundTrip(t *testing.T) {
+	s := graphSST(t, "Hub", "Node")
+	paris := s.MustCreateNode("Hub", "Paris", map[string]interface{}{"motto": "Fluctuat nec mergitur", "quote": "\"<&>\"\n"}, 2)
+	france := s.MustCreateNode("Hub", "France", nil, 1)
+	london := s.MustCreateNode("Node", "London", nil, 0.5)
+	s.MustCreateLink(paris, "part_of", france, map[string]interface{}{"since": 987.0}, 3)
+	s.MustBlockLink(london, "part_of", france, nil, 1)
+	s.MustTimeline("trip").MustNextEventAt(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), "Node", "arrive", nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteTurtle(&buf, "http://example.org/sst/"))
+	turtle := buf.String()
+	assert.Contains(t, turtle, "<http://example.org/sst/association/part_of> a rdf:Property ;\n\trdfs:subPropertyOf sst:Constitutes ;\n\tsst:key \"part_of\" ;\n\trdfs:label \"is part of\" ;\n")
+	assert.Contains(t, turtle, "<http://example.org/sst/node/Hub/Paris> <http://example.org/sst/association/part_of> <http://example.org/sst/node/Hub/France> .\n")
+	assert.Contains(t, turtle, "<http://example.org/sst/node/Node/London> a sst:Node ;")
+	assert.Contains(t, turtle, "a rdf:Statement, sst:NegativeStatement ;")
+	assert.NotContains(t, turtle, "<http://example.org/sst/node/Node/London> <http://example.org/sst/association/part_of>")
+
+	want, err := s.ExportGraph()
+	assert.NoError(t, err)
+	imported := graphSST(t, "Hub", "Node")
+	assert.NoError(t, imported.ImportTurtle(&buf, "http://example.org/sst/"))
+	got, err := imported.ExportGraph()
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestImportTurtle(t *testing.T) {
+	s := graphSST(t, "Node")
+	err := s.ImportTurtle(strings.NewReader(`
+PREFIX ex: <http://example.org/>
+@prefix sst: <https://github.com/tristanls/sst#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+
+# Associations are properties of a semantic type
+ex:association\/capital_of a <http://www.w3.org/1999/02/22-rdf-syntax-ns#Property> ;
+	rdfs:subPropertyOf sst:Contains ;
+	rdfs:label "is capital of", 'est la capitale de'@fr .
+
+ex:node\/Node\/Paris a sst:Node ; sst:weight 2.5 ; .
+ex:node\/Node\/France a sst:Node .
+ex:node\/Node\/Paris ex:association\/capital_of ex:node\/Node\/France .
+`), "http://example.org/")
+	assert.NoError(t, err)
+	data, err := s.GetNodeData("Node/Paris")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+	g, err := s.ExportGraph()
+	assert.NoError(t, err)
+	assert.Len(t, g.Nodes, 2)
+	assert.Equal(t, 2.5, g.Nodes[1].Weight)
+	assert.Len(t, g.Links, 1)
+	assert.Equal(t, "capital_of", g.Links[0].Association)
+	assert.Equal(t, "Node/Paris", g.Links[0].From)
+	assert.Equal(t, 1.0, g.Links[0].Weight)
+	assert.Equal(t, "is capital of", s.association("capital_of").Fwd)
+
+	err = s.ImportTurtle(strings.NewReader(`<a> <b> <c> .`), "http://example.org/")
+	assert.Equal(t, invalidTurtle, errors.Cause(err))
+	err = s.ImportTurtle(strings.NewReader(`<http://a> <http://b> [ <http://c> "d" ] .`), "http://example.org/")
+	assert.Equal(t, invalidTurtle, errors.Cause(err))
+}
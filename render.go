@@ -0,0 +1,76 @@
+package sst
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RenderLink renders the link as a sentence using the phrases of its association,
+// e.g. "Paris is part of France". A link walked forward is rendered from Link.From
+// to Link.To, a link walked backward from Link.To to Link.From. Negated links are
+// rendered using the negated phrases.
+func (s *SST) RenderLink(link *Link, forward bool) (string, error) {
+	if link == nil {
+		return "", nilLink
+	}
+	a := s.association(link.SID)
+	if a == nil {
+		return "", errors.Wrapf(unknownAssociation, "sst: failed to render link: %v", link.Key)
+	}
+	negated, err := LinkNegated(link)
+	if err != nil {
+		return "", err
+	}
+	from, to := link.From, link.To
+	if !forward {
+		from, to = to, from
+	}
+	return nodeName(from) + " " + a.Phrase(forward, negated) + " " + nodeName(to), nil
+}
+
+// RenderPath renders the path as a paragraph with a sentence per hop.
+func (s *SST) RenderPath(path *Path) (string, error) {
+	sentences := make([]string, 0, len(path.Hops))
+	for _, hop := range path.Hops {
+		sentence, err := s.RenderLink(hop.Link, hop.Forward)
+		if err != nil {
+			return "", err
+		}
+		sentences = append(sentences, sentence)
+	}
+	return paragraph(sentences), nil
+}
+
+// RenderNeighbourhood renders links around the node, such as those returned by
+// Neighbours, as a paragraph with a sentence per link. Links to the node are
+// walked backward so that sentences about the node start with the node.
+func (s *SST) RenderNeighbourhood(node *Node, links []*Link) (string, error) {
+	id, err := NodeID(node)
+	if err != nil {
+		return "", err
+	}
+	sentences := make([]string, 0, len(links))
+	for _, link := range links {
+		sentence, err := s.RenderLink(link, link.To != id || link.From == id)
+		if err != nil {
+			return "", err
+		}
+		sentences = append(sentences, sentence)
+	}
+	return paragraph(sentences), nil
+}
+
+// nodeName returns the name of the node with the designated _id
+func nodeName(id string) string {
+	_, key := splitNodeID(id)
+	return FromDocumentKey(key)
+}
+
+// paragraph joins sentences into a paragraph
+func paragraph(sentences []string) string {
+	if len(sentences) == 0 {
+		return ""
+	}
+	return strings.Join(sentences, ". ") + "."
+}
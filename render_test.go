@@ -0,0 +1,54 @@
+package sst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderLink(t *testing.T) {
+	s := memorySST(t)
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	london := s.MustCreateNode("Node", "London", nil, 1)
+
+	link := s.MustCreateLink(paris, "part_of", france, nil, 1)
+	sentence, err := s.RenderLink(link, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris is part of France", sentence)
+	sentence, err = s.RenderLink(link, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "France incorporates Paris", sentence)
+
+	blocked := s.MustBlockLink(london, "part_of", france, nil, 1)
+	sentence, err = s.RenderLink(blocked, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "London is not part of France", sentence)
+	sentence, err = s.RenderLink(blocked, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "France doesn't incorporate London", sentence)
+
+	_, err = s.RenderLink(&Link{Key: "+x", SID: "unknown"}, true)
+	assert.Error(t, err)
+}
+
+func TestRenderPathAndNeighbourhood(t *testing.T) {
+	s := memorySST(t)
+	emily := s.MustCreateNode("Node", "Emily", nil, 1)
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	s.MustCreateLink(emily, "coactive", paris, nil, 1)
+	s.MustCreateLink(paris, "part_of", france, nil, 1)
+
+	paths, err := s.Paths(emily, france, &PathOptions{Direction: Any})
+	assert.NoError(t, err)
+	text, err := s.RenderPath(paths[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "Emily occurred together with Paris. Paris is part of France.", text)
+
+	_, links, err := s.Traverse(france, &TraversalOptions{Direction: Any, Depth: 2})
+	assert.NoError(t, err)
+	text, err = s.RenderNeighbourhood(france, links)
+	assert.NoError(t, err)
+	assert.Equal(t, "France incorporates Paris. Emily occurred together with Paris.", text)
+}
@@ -0,0 +1,288 @@
+package sst
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	invalidSchema   = errors.New("sst: invalid schema")
+	schemaViolation = errors.New("sst: schema violation")
+)
+
+// FieldType is the JSON type of a Data field
+type FieldType string
+
+const (
+	// AnyType fields may hold any value but null
+	AnyType FieldType = "any"
+	// ArrayType fields hold JSON arrays, such as slices
+	ArrayType FieldType = "array"
+	// BooleanType fields hold booleans
+	BooleanType FieldType = "boolean"
+	// NumberType fields hold numbers
+	NumberType FieldType = "number"
+	// ObjectType fields hold JSON objects, such as maps and structs
+	ObjectType FieldType = "object"
+	// StringType fields hold strings
+	StringType FieldType = "string"
+)
+
+// Schema declares constraints on the nodes and links of a Semantic Spacetime.
+// Nodes and links are validated by CreateNode, CreateNodes, CreateLink,
+// BlockLink, IncrementLink, CreateLinks, the event recording methods and the
+// Batch equivalents. Nodes and links imported with ImportGraph are not validated.
+type Schema struct {
+	// Nodes declares the data of the nodes of node collections, by name. Declared
+	// node collections that are not configured may be added with AddNodeCollection.
+	Nodes map[string]*NodeSchema
+	// Associations declares the node collections linked by associations, by key
+	Associations map[string]*AssociationSchema
+	// Backend, if true, also pushes the schema to the collection-level schema
+	// validation of the Backend, if supported, such as ArangoDB's
+	Backend bool
+}
+
+// NodeSchema declares the data of the nodes of a node collection
+type NodeSchema struct {
+	// Required declares the Data fields nodes must hold and their types
+	Required map[string]FieldType
+}
+
+// AssociationSchema declares the node collections linked by an association
+type AssociationSchema struct {
+	// From are the node collections links may come from, any if empty
+	From []string
+	// To are the node collections links may go to, any if empty
+	To []string
+}
+
+// IsSchemaViolation returns true if the error designates a node or link that
+// violates the Schema, false otherwise.
+func IsSchemaViolation(err error) bool {
+	return errors.Cause(err) == schemaViolation
+}
+
+// check returns an error if the schema designates node collections that are
+// neither configured nor declared by the schema, or unknown field types. Node
+// collections declared by the schema but not configured may be added with
+// AddNodeCollection.
+func (schema *Schema) check(collections []string) error {
+	configured := make(map[string]bool)
+	for _, name := range collections {
+		configured[name] = true
+	}
+	for name, n := range schema.Nodes {
+		configured[name] = true
+		for field, typ := range n.Required {
+			switch typ {
+			case AnyType, ArrayType, BooleanType, NumberType, ObjectType, StringType:
+			default:
+				return errors.Wrapf(invalidSchema, "sst: unknown type of %v data field %q: %v", name, field, typ)
+			}
+		}
+	}
+	for key, a := range schema.Associations {
+		for _, name := range append(append([]string{}, a.From...), a.To...) {
+			if !configured[name] {
+				return errors.Wrapf(invalidSchema, "sst: schema of association %v designates unknown node collection: %v", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// covers returns true if the schema declares the node collection or designates
+// it as linked by an association, false otherwise
+func (schema *Schema) covers(name string) bool {
+	if _, ok := schema.Nodes[name]; ok {
+		return true
+	}
+	for _, a := range schema.Associations {
+		if contains(a.From, name) || contains(a.To, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNode returns an error if the node violates the schema. A node
+// carrying no data, weight or time does not update an existing node, so it is
+// only validated if the node does not exist. Reading the node from the Backend
+// within a transaction would deadlock, so such nodes must be validated before
+// the transaction begins.
+func (s *SST) validateNode(ctx context.Context, node *Node) error {
+	schema := s.config.Schema
+	if schema == nil {
+		return nil
+	}
+	kind := strings.TrimSuffix(node.Prefix, "/")
+	n := schema.Nodes[kind]
+	if n == nil || len(n.Required) == 0 {
+		return nil
+	}
+	if node.Data == nil && node.Weight == 0.0 && node.Time == nil {
+		_, err := s.backend.ReadNode(ctx, MustNodeID(node))
+		if err == nil {
+			return nil
+		}
+		if !IsNotFound(err) {
+			return err
+		}
+	}
+	for _, field := range sortedFields(n.Required) {
+		typ := n.Required[field]
+		value, ok := node.Data[field]
+		if !ok {
+			return errors.Wrapf(schemaViolation, "sst: %v node %v lacks required %v data field %q", kind, node.Key, typ, field)
+		}
+		actual := fieldType(value)
+		if actual == "null" || (typ != AnyType && actual != typ) {
+			return errors.Wrapf(schemaViolation, "sst: %v node %v data field %q must be a %v, not %v", kind, node.Key, field, typ, actual)
+		}
+	}
+	return nil
+}
+
+// validateLink returns an error if the schema does not allow the association to
+// link the node collections of the link
+func (s *SST) validateLink(a *Association, link *Link) error {
+	schema := s.config.Schema
+	if schema == nil {
+		return nil
+	}
+	as := schema.Associations[a.Key]
+	if as == nil {
+		return nil
+	}
+	from, _ := splitNodeID(link.From)
+	if len(as.From) > 0 && !contains(as.From, from) {
+		return errors.Wrapf(schemaViolation, "sst: %v links from %v nodes, not from %v", a.Key, strings.Join(as.From, " or "), link.From)
+	}
+	to, _ := splitNodeID(link.To)
+	if len(as.To) > 0 && !contains(as.To, to) {
+		return errors.Wrapf(schemaViolation, "sst: %v links to %v nodes, not to %v", a.Key, strings.Join(as.To, " or "), link.To)
+	}
+	return nil
+}
+
+// nodeJSONSchema returns the JSON Schema of the nodes of the collection, nil if
+// the schema does not constrain them
+func (schema *Schema) nodeJSONSchema(collection string) map[string]interface{} {
+	n := schema.Nodes[collection]
+	if n == nil || len(n.Required) == 0 {
+		return nil
+	}
+	fields := sortedFields(n.Required)
+	properties := make(map[string]interface{})
+	for _, field := range fields {
+		if typ := n.Required[field]; typ == AnyType {
+			properties[field] = map[string]interface{}{"not": map[string]interface{}{"type": "null"}}
+		} else {
+			properties[field] = map[string]interface{}{"type": string(typ)}
+		}
+	}
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"data"},
+		"properties": map[string]interface{}{
+			"data": map[string]interface{}{"type": "object", "required": fields, "properties": properties},
+		},
+	}
+}
+
+// linkJSONSchema returns the JSON Schema of the links of the collection of the
+// SemanticType, nil if the schema does not constrain them. Links of each
+// constrained association must match the node collections of their _from and _to.
+func (schema *Schema) linkJSONSchema(associations []*Association, typ SemanticType) map[string]interface{} {
+	rules := make([]interface{}, 0)
+	for _, a := range associations {
+		as := schema.Associations[a.Key]
+		if as == nil || a.SemanticType.abs() != typ || (len(as.From) == 0 && len(as.To) == 0) {
+			continue
+		}
+		endpoints := make(map[string]interface{})
+		if len(as.From) > 0 {
+			endpoints["_from"] = map[string]interface{}{"type": "string", "pattern": collectionsPattern(as.From)}
+		}
+		if len(as.To) > 0 {
+			endpoints["_to"] = map[string]interface{}{"type": "string", "pattern": collectionsPattern(as.To)}
+		}
+		rules = append(rules, map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"properties": map[string]interface{}{"semantics": map[string]interface{}{"not": map[string]interface{}{"enum": []string{a.Key}}}}},
+				map[string]interface{}{"properties": endpoints},
+			},
+		})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"allOf": rules}
+}
+
+// collectionsPattern returns the pattern of the _ids of nodes of the collections
+func collectionsPattern(collections []string) string {
+	quoted := make([]string, 0, len(collections))
+	for _, name := range collections {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")/"
+}
+
+// fieldType returns the JSON type of the value, "null" for nil values
+func fieldType(value interface{}) FieldType {
+	encoded, err := json.Marshal(value)
+	if err != nil || len(encoded) == 0 {
+		return "null"
+	}
+	switch encoded[0] {
+	case '"':
+		return StringType
+	case 't', 'f':
+		return BooleanType
+	case '[':
+		return ArrayType
+	case '{':
+		return ObjectType
+	case 'n':
+		return "null"
+	}
+	return NumberType
+}
+
+// sortedFields returns the names of the fields, sorted
+func sortedFields(fields map[string]FieldType) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contains returns true if the names contain the name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns the names other than the name
+func without(names []string, name string) []string {
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != name {
+			res = append(res, n)
+		}
+	}
+	return res
+}
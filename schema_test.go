@@ -0,0 +1,199 @@
+package sst
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func schemaSST(t *testing.T, backend Backend) *SST {
+	s, err := NewSST(&Config{
+		Backend:         backend,
+		Name:            "memory",
+		NodeCollections: []string{"Person", "Country", "Location"},
+		Schema: &Schema{
+			Nodes: map[string]*NodeSchema{
+				"Person": {Required: map[string]FieldType{"description": StringType, "number": NumberType}},
+			},
+			Associations: map[string]*AssociationSchema{
+				"part_of": {From: []string{"Location"}, To: []string{"Country"}},
+			},
+			Backend: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	return s
+}
+
+func TestSchemaNodes(t *testing.T) {
+	s := schemaSST(t, NewMemoryBackend())
+	_, err := s.CreateNode("Person", "Mark", map[string]interface{}{"description": "Professor"}, 1)
+	assert.True(t, IsSchemaViolation(err))
+	assert.Contains(t, err.Error(), `lacks required number data field "number"`)
+	_, err = s.CreateNode("Person", "Mark", map[string]interface{}{"description": "Professor", "number": "123456"}, 1)
+	assert.True(t, IsSchemaViolation(err))
+	assert.Contains(t, err.Error(), `data field "number" must be a number, not string`)
+	_, err = s.CreateNode("Person", "Mark", nil, 0)
+	assert.True(t, IsSchemaViolation(err))
+
+	mark, err := s.CreateNode("Person", "Mark", map[string]interface{}{"description": "Professor", "number": 123456}, 1)
+	assert.NoError(t, err)
+	_, err = s.CreateNode("Person", "Mark", nil, 0)
+	assert.NoError(t, err)
+	_, err = s.CreateNode("Person", "Mark", nil, 2)
+	assert.True(t, IsSchemaViolation(err))
+	_, err = s.CreateNode("Country", "UK", nil, 0)
+	assert.NoError(t, err)
+
+	nodes, err := s.CreateNodes([]*Node{
+		{Prefix: "Person/", Key: "Emily", Weight: 1},
+		{Prefix: "Country/", Key: "France", Weight: 1},
+	})
+	errs := err.(BatchError)
+	assert.True(t, IsSchemaViolation(errs[0]))
+	assert.NoError(t, errs[1])
+	assert.Nil(t, nodes[0])
+	assert.NotNil(t, nodes[1])
+
+	b := s.NewBatch()
+	b.CreateNode("Person", "Emily", map[string]interface{}{"description": "Engineer"}, 1)
+	assert.True(t, IsSchemaViolation(b.Commit()))
+
+	_, err = s.NextEvent("Person", "Captain", nil)
+	assert.True(t, IsSchemaViolation(err))
+	assert.NotNil(t, mark)
+}
+
+func TestSchemaLinks(t *testing.T) {
+	s := schemaSST(t, NewMemoryBackend())
+	paris := s.MustCreateNode("Location", "Paris", nil, 1)
+	france := s.MustCreateNode("Country", "France", nil, 1)
+	mark := s.MustCreateNode("Person", "Mark", map[string]interface{}{"description": "Professor", "number": 1}, 1)
+
+	_, err := s.CreateLink(paris, "part_of", france, nil, 1)
+	assert.NoError(t, err)
+	_, err = s.CreateLink(france, "part_of", paris, nil, 1)
+	assert.True(t, IsSchemaViolation(err))
+	assert.Contains(t, err.Error(), "part_of links from Location nodes, not from Country/France")
+	_, err = s.BlockLink(paris, "part_of", mark, nil, 1)
+	assert.True(t, IsSchemaViolation(err))
+	assert.Contains(t, err.Error(), "part_of links to Country nodes, not to Person/Mark")
+	_, err = s.CreateLink(mark, "related", paris, nil, 1)
+	assert.NoError(t, err)
+
+	_, err = s.CreateLinks([]*Link{{From: "Person/Mark", SID: "part_of", To: "Country/France", Weight: 1}})
+	assert.True(t, IsSchemaViolation(err.(BatchError)[0]))
+	assert.True(t, IsSchemaViolation(s.NewBatch().CreateLink(mark, "part_of", france, nil, 1)))
+}
+
+func testSchemaBatch(t *testing.T, s *SST) {
+	b := s.NewBatch()
+	b.CreateNode("Person", "Mark", nil, 0)
+	assert.True(t, IsSchemaViolation(b.Commit()))
+
+	b = s.NewBatch()
+	mark := b.CreateNode("Person", "Mark", map[string]interface{}{"description": "Professor", "number": 1}, 1)
+	assert.NoError(t, b.Commit())
+
+	b = s.NewBatch()
+	b.CreateNode("Person", "Mark", nil, 0)
+	uk := b.CreateNode("Country", "UK", nil, 1)
+	assert.NoError(t, b.CreateLink(mark, "related", uk, nil, 1))
+	assert.NoError(t, b.Commit())
+	_, err := s.GetLink(mark, "related", uk, false)
+	assert.NoError(t, err)
+}
+
+func TestSchemaBatch(t *testing.T) {
+	testSchemaBatch(t, schemaSST(t, NewMemoryBackend()))
+	s := schemaSST(t, NewBoltBackend(filepath.Join(t.TempDir(), "sst.db")))
+	defer s.Close()
+	testSchemaBatch(t, s)
+}
+
+func TestSchemaCheck(t *testing.T) {
+	for _, schema := range []*Schema{
+		{Associations: map[string]*AssociationSchema{"part_of": {From: []string{"Missing"}}}},
+		{Nodes: map[string]*NodeSchema{"Node": {Required: map[string]FieldType{"name": "text"}}}},
+		{Associations: map[string]*AssociationSchema{"part_of": {To: []string{"Missing"}}}},
+	} {
+		_, err := NewSST(&Config{Backend: NewMemoryBackend(), NodeCollections: []string{"Node"}, Schema: schema})
+		assert.Equal(t, invalidSchema, errors.Cause(err))
+	}
+}
+
+func TestSchemaNodeCollections(t *testing.T) {
+	s, err := NewSST(&Config{
+		Backend:         NewMemoryBackend(),
+		Name:            "memory",
+		NodeCollections: []string{"Person", "Country"},
+		Schema: &Schema{
+			Nodes: map[string]*NodeSchema{
+				"Company": {Required: map[string]FieldType{"number": NumberType}},
+			},
+			Associations: map[string]*AssociationSchema{
+				"part_of": {From: []string{"Company"}, To: []string{"Country"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = s.AddNodeCollection("Region")
+	assert.True(t, IsSchemaViolation(err))
+	assert.NoError(t, s.AddNodeCollection("Person"))
+	assert.NoError(t, s.AddNodeCollection("Company"))
+	_, err = s.CreateNode("Company", "Acme", nil, 1)
+	assert.True(t, IsSchemaViolation(err))
+	acme, err := s.CreateNode("Company", "Acme", map[string]interface{}{"number": 42}, 1)
+	assert.NoError(t, err)
+	uk := s.MustCreateNode("Country", "UK", nil, 1)
+	_, err = s.CreateLink(acme, "part_of", uk, nil, 1)
+	assert.NoError(t, err)
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	schema := &Schema{
+		Nodes: map[string]*NodeSchema{"Person": {Required: map[string]FieldType{"number": NumberType, "tags": AnyType}}},
+		Associations: map[string]*AssociationSchema{
+			"part_of": {From: []string{"Location"}, To: []string{"Country", "Region"}},
+		},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"type":     "object",
+		"required": []string{"data"},
+		"properties": map[string]interface{}{
+			"data": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"number", "tags"},
+				"properties": map[string]interface{}{
+					"number": map[string]interface{}{"type": "number"},
+					"tags":   map[string]interface{}{"not": map[string]interface{}{"type": "null"}},
+				},
+			},
+		},
+	}, schema.nodeJSONSchema("Person"))
+	assert.Nil(t, schema.nodeJSONSchema("Country"))
+
+	associations := []*Association{
+		{Key: "part_of", SemanticType: -Contains},
+		{Key: "contains", SemanticType: Contains},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{
+				"anyOf": []interface{}{
+					map[string]interface{}{"properties": map[string]interface{}{"semantics": map[string]interface{}{"not": map[string]interface{}{"enum": []string{"part_of"}}}}},
+					map[string]interface{}{"properties": map[string]interface{}{
+						"_from": map[string]interface{}{"type": "string", "pattern": "^(Location)/"},
+						"_to":   map[string]interface{}{"type": "string", "pattern": "^(Country|Region)/"},
+					}},
+				},
+			},
+		},
+	}, schema.linkJSONSchema(associations, Contains))
+	assert.Nil(t, schema.linkJSONSchema(associations, Near))
+}
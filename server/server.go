@@ -0,0 +1,607 @@
+// Package server exposes the operations of a Semantic Spacetime as a REST API
+// with JSON request and response bodies. The API is described by the OpenAPI
+// document served at /openapi.json.
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tristanls/sst"
+)
+
+var (
+	badRequest = errors.New("server: bad request")
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Server serves the REST API of an SST
+type Server struct {
+	mux *http.ServeMux
+	s   *sst.SST
+}
+
+// New creates a Server serving the REST API of the SST
+func New(s *sst.SST) *Server {
+	srv := &Server{
+		mux: http.NewServeMux(),
+		s:   s,
+	}
+	srv.mux.HandleFunc("/associations", srv.associations)
+	srv.mux.HandleFunc("/links", srv.links)
+	srv.mux.HandleFunc("/links/block", srv.blockLink)
+	srv.mux.HandleFunc("/links/increment", srv.incrementLink)
+	srv.mux.HandleFunc("/nodes", srv.nodes)
+	srv.mux.HandleFunc("/nodes/", srv.node)
+	srv.mux.HandleFunc("/openapi.json", srv.openAPI)
+	srv.mux.HandleFunc("/timelines/", srv.timeline)
+	return srv
+}
+
+// ServeHTTP serves the REST API
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// association is the JSON representation of an sst.Association
+type association struct {
+	Key          string `json:"key"`
+	SemanticType string `json:"semanticType"`
+	Fwd          string `json:"fwd"`
+	Bwd          string `json:"bwd"`
+	Nfwd         string `json:"nfwd"`
+	Nbwd         string `json:"nbwd"`
+}
+
+// event is the JSON representation of an event to create
+type event struct {
+	Kind string                 `json:"kind"`
+	Key  string                 `json:"key"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// link is the JSON representation of an sst.Link
+type link struct {
+	Key         string                 `json:"key"`
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Association string                 `json:"association"`
+	Negated     bool                   `json:"negated"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Weight      float64                `json:"weight"`
+	Time        *time.Time             `json:"time,omitempty"`
+}
+
+// linkRequest designates a link to create or update
+type linkRequest struct {
+	From        string                 `json:"from"`
+	Association string                 `json:"association"`
+	To          string                 `json:"to"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Weight      float64                `json:"weight"`
+}
+
+// node is the JSON representation of an sst.Node
+type node struct {
+	ID     string                 `json:"id"`
+	Kind   string                 `json:"kind"`
+	Key    string                 `json:"key"`
+	Name   string                 `json:"name"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Weight float64                `json:"weight"`
+	Time   *time.Time             `json:"time,omitempty"`
+}
+
+// traversal is the JSON representation of the result of a traversal
+type traversal struct {
+	Nodes []*node `json:"nodes"`
+	Links []*link `json:"links"`
+}
+
+func (srv *Server) associations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		associations := make([]*association, 0)
+		for _, a := range srv.s.Associations() {
+			associations = append(associations, associationOf(a))
+		}
+		respond(w, http.StatusOK, associations)
+	case http.MethodPost:
+		var req association
+		if !decode(w, r, &req) {
+			return
+		}
+		typ, err := parseSemanticType(req.SemanticType)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		a := &sst.Association{Key: req.Key, SemanticType: typ, Fwd: req.Fwd, Bwd: req.Bwd, Nfwd: req.Nfwd, Nbwd: req.Nbwd}
+		err = srv.s.CreateAssociationContext(r.Context(), a)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		respond(w, http.StatusCreated, associationOf(a))
+	default:
+		notAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (srv *Server) links(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		srv.findLinks(w, r)
+	case http.MethodPost:
+		var req linkRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		l, err := srv.s.CreateLinkByIDContext(r.Context(), req.From, req.Association, req.To, req.Data, req.Weight)
+		srv.respondLink(w, l, err)
+	case http.MethodDelete:
+		q := r.URL.Query()
+		from, err := nodeOf(q.Get("from"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		to, err := nodeOf(q.Get("to"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		negated, err := parseBool(q.Get("negated"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		err = srv.s.DeleteLinkContext(r.Context(), from, q.Get("association"), to, negated)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		notAllowed(w, http.MethodGet, http.MethodPost, http.MethodDelete)
+	}
+}
+
+// findLinks serves the link designated by the from, association, to and negated
+// query parameters, or the links from, to or between the designated nodes if no
+// association is designated
+func (srv *Server) findLinks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var from, to *sst.Node
+	var err error
+	if v := q.Get("from"); v != "" {
+		from, err = nodeOf(v)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		to, err = nodeOf(v)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+	}
+	if a := q.Get("association"); a != "" {
+		if from == nil || to == nil {
+			fail(w, errors.Wrap(badRequest, "server: from and to are required with association"))
+			return
+		}
+		negated, err := parseBool(q.Get("negated"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		l, err := srv.s.GetLinkContext(r.Context(), from, a, to, negated)
+		srv.respondLink(w, l, err)
+		return
+	}
+	var links []*sst.Link
+	switch {
+	case from != nil && to != nil:
+		links, err = srv.s.LinksBetweenContext(r.Context(), from, to)
+	case from != nil:
+		links, err = srv.s.LinksFromContext(r.Context(), from)
+	case to != nil:
+		links, err = srv.s.LinksToContext(r.Context(), to)
+	default:
+		err = errors.Wrap(badRequest, "server: from or to is required")
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	res := make([]*link, 0, len(links))
+	for _, l := range links {
+		res = append(res, linkJSON(l))
+	}
+	respond(w, http.StatusOK, res)
+}
+
+func (srv *Server) blockLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		notAllowed(w, http.MethodPost)
+		return
+	}
+	var req linkRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	l, err := srv.s.BlockLinkByIDContext(r.Context(), req.From, req.Association, req.To, req.Data, req.Weight)
+	srv.respondLink(w, l, err)
+}
+
+func (srv *Server) incrementLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		notAllowed(w, http.MethodPost)
+		return
+	}
+	var req linkRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	from, err := nodeOf(req.From)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	to, err := nodeOf(req.To)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	l, err := srv.s.IncrementLinkContext(r.Context(), from, req.Association, to, req.Data)
+	srv.respondLink(w, l, err)
+}
+
+func (srv *Server) nodes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		srv.listNodes(w, r)
+	case http.MethodPost:
+		var req node
+		if !decode(w, r, &req) {
+			return
+		}
+		n, err := srv.s.CreateNodeContext(r.Context(), req.Kind, req.Key, req.Data, req.Weight)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		respond(w, http.StatusCreated, nodeJSON(n))
+	default:
+		notAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (srv *Server) listNodes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	if kind == "" {
+		fail(w, errors.Wrap(badRequest, "server: kind is required"))
+		return
+	}
+	filter := &sst.NodeFilter{NamePrefix: q.Get("prefix")}
+	if v := q.Get("minWeight"); v != "" {
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			fail(w, errors.Wrapf(badRequest, "server: invalid minWeight: %v", v))
+			return
+		}
+		filter.MinWeight = weight
+	}
+	page := &sst.Page{}
+	for name, v := range map[string]*int{"offset": &page.Offset, "limit": &page.Limit} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				fail(w, errors.Wrapf(badRequest, "server: invalid %v: %v", name, s))
+				return
+			}
+			*v = n
+		}
+	}
+	nodes, err := srv.s.ListNodesContext(r.Context(), kind, filter, page)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	respond(w, http.StatusOK, nodesJSON(nodes))
+}
+
+// node serves /nodes/{kind}/{name} and /nodes/{kind}/{name}/neighbours
+func (srv *Server) node(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/nodes/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "neighbours") {
+		http.NotFound(w, r)
+		return
+	}
+	n := &sst.Node{Prefix: parts[0] + "/", Key: sst.ToDocumentKey(parts[1])}
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 3:
+		srv.neighbours(w, r, n)
+	case r.Method == http.MethodGet:
+		n, err := srv.s.GetNodeContext(r.Context(), parts[0]+"/"+parts[1])
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		respond(w, http.StatusOK, nodeJSON(n))
+	case r.Method == http.MethodDelete && len(parts) == 2:
+		cascade, err := parseBool(r.URL.Query().Get("cascade"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		err = srv.s.DeleteNodeContext(r.Context(), n, cascade)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 3:
+		notAllowed(w, http.MethodGet)
+	default:
+		notAllowed(w, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (srv *Server) neighbours(w http.ResponseWriter, r *http.Request, start *sst.Node) {
+	q := r.URL.Query()
+	opts := &sst.TraversalOptions{Direction: sst.Any, Depth: 1}
+	for _, t := range q["type"] {
+		typ, err := parseSemanticType(t)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		opts.SemanticTypes = append(opts.SemanticTypes, typ)
+	}
+	if d := q.Get("direction"); d != "" {
+		dir, err := parseDirection(d)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		opts.Direction = dir
+	}
+	if d := q.Get("depth"); d != "" {
+		depth, err := strconv.Atoi(d)
+		if err != nil || depth < 1 {
+			fail(w, errors.Wrapf(badRequest, "server: invalid depth: %v", d))
+			return
+		}
+		opts.Depth = depth
+	}
+	negated, err := parseBool(q.Get("negated"))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	opts.IncludeNegated = negated
+	nodes, links, err := srv.s.TraverseContext(r.Context(), start, opts)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	res := &traversal{Nodes: make([]*node, 0, len(nodes)), Links: make([]*link, 0, len(links))}
+	for _, n := range nodes {
+		res.Nodes = append(res.Nodes, nodeJSON(n))
+	}
+	for _, l := range links {
+		res.Links = append(res.Links, linkJSON(l))
+	}
+	respond(w, http.StatusOK, res)
+}
+
+func (srv *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		notAllowed(w, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
+
+// timeline serves /timelines/{name}/events
+func (srv *Server) timeline(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/timelines/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "events" {
+		http.NotFound(w, r)
+		return
+	}
+	t, err := srv.s.TimelineContext(r.Context(), parts[0])
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		from, err := parseTime(q.Get("from"), time.Time{})
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		to, err := parseTime(q.Get("to"), time.Now().Add(time.Hour))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		events, err := t.EventsContext(r.Context(), from, to)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		respond(w, http.StatusOK, nodesJSON(events))
+	case http.MethodPost:
+		var req struct {
+			Time   *time.Time `json:"time"`
+			Events []*event   `json:"events"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		if len(req.Events) == 0 {
+			fail(w, errors.Wrap(badRequest, "server: no events"))
+			return
+		}
+		at := time.Now()
+		if req.Time != nil {
+			at = *req.Time
+		}
+		kinds, keys, data := make([]string, 0), make([]string, 0), make([]map[string]interface{}, 0)
+		for _, e := range req.Events {
+			kinds, keys, data = append(kinds, e.Kind), append(keys, e.Key), append(data, e.Data)
+		}
+		events, err := t.NextEventsAtContext(r.Context(), at, kinds, keys, data)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		respond(w, http.StatusCreated, nodesJSON(events))
+	default:
+		notAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// respondLink responds with the link created or updated by a link operation
+func (srv *Server) respondLink(w http.ResponseWriter, l *sst.Link, err error) {
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	respond(w, http.StatusOK, linkJSON(l))
+}
+
+// respond writes the JSON encoded body with the designated status
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// fail responds with the status designated by the error
+func fail(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Cause(err) == badRequest:
+		status = http.StatusBadRequest
+	case sst.IsNotFound(err):
+		status = http.StatusNotFound
+	case sst.IsUnknownAssociation(err), sst.IsSchemaViolation(err):
+		status = http.StatusUnprocessableEntity
+	case sst.IsAssociationConflict(err), sst.IsNodeHasLinks(err):
+		status = http.StatusConflict
+	}
+	respond(w, status, map[string]string{"error": err.Error()})
+}
+
+// decode decodes the JSON request body, responding with an error if it is invalid
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		fail(w, errors.Wrapf(badRequest, "server: invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// notAllowed responds that the method is not allowed
+func notAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	respond(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+}
+
+func associationOf(a *sst.Association) *association {
+	return &association{Key: a.Key, SemanticType: a.SemanticType.String(), Fwd: a.Fwd, Bwd: a.Bwd, Nfwd: a.Nfwd, Nbwd: a.Nbwd}
+}
+
+func linkJSON(l *sst.Link) *link {
+	negated, _ := sst.LinkNegated(l)
+	return &link{Key: l.Key, From: l.From, To: l.To, Association: l.SID, Negated: negated, Data: l.Data, Weight: l.Weight, Time: l.Time}
+}
+
+func nodeJSON(n *sst.Node) *node {
+	return &node{ID: sst.MustNodeID(n), Kind: strings.TrimSuffix(n.Prefix, "/"), Key: n.Key, Name: n.Label(), Data: n.Data, Weight: n.Weight, Time: n.Time}
+}
+
+func nodesJSON(nodes []*sst.Node) []*node {
+	res := make([]*node, 0, len(nodes))
+	for _, n := range nodes {
+		res = append(res, nodeJSON(n))
+	}
+	return res
+}
+
+// nodeOf returns the node designated by the _id
+func nodeOf(id string) (*sst.Node, error) {
+	i := strings.Index(id, "/")
+	if i < 1 || i == len(id)-1 {
+		return nil, errors.Wrapf(badRequest, "server: node id must be <collection>/<key>: %q", id)
+	}
+	return &sst.Node{Prefix: id[:i+1], Key: id[i+1:]}, nil
+}
+
+// parseSemanticType parses the name of a SemanticType, such as "Contains" or
+// "Constitutes", optionally negated as in "-Contains", or its integer value
+func parseSemanticType(s string) (sst.SemanticType, error) {
+	if i, err := strconv.Atoi(s); err == nil {
+		return sst.SemanticType(i), nil
+	}
+	negate := strings.HasPrefix(s, "-")
+	name := strings.TrimPrefix(s, "-")
+	for _, typ := range []sst.SemanticType{sst.Near, sst.Follows, -sst.Follows, sst.Contains, -sst.Contains, sst.Expresses, -sst.Expresses} {
+		if strings.EqualFold(typ.String(), name) {
+			if negate {
+				return -typ, nil
+			}
+			return typ, nil
+		}
+	}
+	return 0, errors.Wrapf(badRequest, "server: invalid semantic type: %q", s)
+}
+
+func parseDirection(s string) (sst.Direction, error) {
+	for _, dir := range []sst.Direction{sst.Outbound, sst.Inbound, sst.Any} {
+		if strings.EqualFold(dir.String(), s) {
+			return dir, nil
+		}
+	}
+	return 0, errors.Wrapf(badRequest, "server: invalid direction: %q", s)
+}
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.Wrapf(badRequest, "server: invalid boolean: %q", s)
+	}
+	return b, nil
+}
+
+func parseTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(badRequest, "server: invalid time: %q", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tristanls/sst"
+)
+
+func TestServer(t *testing.T) {
+	s, err := sst.NewSST(&sst.Config{
+		Backend:         sst.NewMemoryBackend(),
+		Name:            "memory",
+		NodeCollections: []string{"Node"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create SST: %v", err)
+	}
+	srv := httptest.NewServer(New(s))
+	defer srv.Close()
+
+	do := func(method, path, body string, res interface{}) int {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to %v %v: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if res != nil {
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+		}
+		return resp.StatusCode
+	}
+
+	var n node
+	assert.Equal(t, http.StatusCreated, do("POST", "/nodes", `{"kind":"Node","key":"Paris","data":{"country":"France"}}`, &n))
+	assert.Equal(t, "Node/Paris", n.ID)
+	assert.Equal(t, http.StatusCreated, do("POST", "/nodes", `{"kind":"Node","key":"France"}`, nil))
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes/Node/Paris", "", &n))
+	assert.Equal(t, "France", n.Data["country"])
+	assert.Equal(t, "Paris", n.Name)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/nodes/Node/Lyon", "", nil))
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/nodes", `{`, nil))
+
+	var a association
+	assert.Equal(t, http.StatusCreated, do("POST", "/associations", `{"key":"capital_of","semanticType":"Contains","fwd":"is capital of","bwd":"has capital"}`, &a))
+	assert.Equal(t, "Contains", a.SemanticType)
+	assert.Equal(t, http.StatusConflict, do("POST", "/associations", `{"key":"capital_of","semanticType":"Near"}`, nil))
+
+	var l link
+	assert.Equal(t, http.StatusOK, do("POST", "/links", `{"from":"Node/Paris","association":"capital_of","to":"Node/France","weight":1}`, &l))
+	assert.Equal(t, "capital_of", l.Association)
+	assert.Equal(t, http.StatusOK, do("POST", "/links/increment", `{"from":"Node/Paris","association":"capital_of","to":"Node/France"}`, &l))
+	assert.Equal(t, 2.0, l.Weight)
+	assert.Equal(t, http.StatusOK, do("POST", "/links/block", `{"from":"Node/France","association":"capital_of","to":"Node/Paris","weight":1}`, &l))
+	assert.True(t, l.Negated)
+	assert.Equal(t, http.StatusUnprocessableEntity, do("POST", "/links", `{"from":"Node/Paris","association":"nope","to":"Node/France"}`, nil))
+
+	assert.Equal(t, http.StatusOK, do("GET", "/links?from=Node/Paris&association=capital_of&to=Node/France", "", &l))
+	assert.Equal(t, 2.0, l.Weight)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/links?from=Node/Paris&association=capital_of&to=Node/France&negated=true", "", nil))
+	var links []*link
+	assert.Equal(t, http.StatusOK, do("GET", "/links?from=Node/Paris&to=Node/France", "", &links))
+	assert.Len(t, links, 2)
+	assert.Equal(t, http.StatusOK, do("GET", "/links?to=Node/France", "", &links))
+	assert.Len(t, links, 1)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/links", "", nil))
+
+	var tr traversal
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes/Node/France/neighbours?type=Constitutes&direction=outbound", "", &tr))
+	assert.Len(t, tr.Nodes, 1)
+	assert.Equal(t, "Node/Paris", tr.Nodes[0].ID)
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes/Node/France/neighbours?negated=true", "", &tr))
+	assert.Len(t, tr.Links, 2)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/nodes/Node/France/neighbours?type=Sideways", "", nil))
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/links?from=Node/France&association=capital_of&to=Node/Paris&negated=true", "", nil))
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes/Node/France/neighbours?negated=true", "", &tr))
+	assert.Len(t, tr.Links, 1)
+
+	var events []*node
+	assert.Equal(t, http.StatusCreated, do("POST", "/timelines/trip/events", `{"time":"2021-06-01T10:00:00Z","events":[{"kind":"Node","key":"arrive"}]}`, &events))
+	assert.Len(t, events, 1)
+	assert.Equal(t, http.StatusCreated, do("POST", "/timelines/trip/events", `{"time":"2021-06-02T10:00:00Z","events":[{"kind":"Node","key":"leave"}]}`, nil))
+	assert.Equal(t, http.StatusOK, do("GET", "/timelines/trip/events?from=2021-06-02T00:00:00Z", "", &events))
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Node/leave", events[0].ID)
+
+	var nodes []*node
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes?kind=Node&offset=1&limit=2", "", &nodes))
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "Node/Paris", nodes[0].ID)
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes?kind=Node&prefix=Fr", "", &nodes))
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/nodes?kind=Node&limit=-1", "", nil))
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/nodes", "", nil))
+	assert.Equal(t, http.StatusCreated, do("POST", "/nodes", `{"kind":"Node","key":"New York"}`, nil))
+	assert.Equal(t, http.StatusOK, do("GET", "/nodes/Node/New%20York", "", &n))
+	assert.Equal(t, "Node/New%20York", n.ID)
+
+	assert.Equal(t, http.StatusConflict, do("DELETE", "/nodes/Node/Paris", "", nil))
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/nodes/Node/Paris?cascade=true", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/nodes/Node/Paris", "", nil))
+
+	var doc map[string]interface{}
+	assert.Equal(t, http.StatusOK, do("GET", "/openapi.json", "", &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Equal(t, http.StatusMethodNotAllowed, do("PUT", "/nodes", "", nil))
+}
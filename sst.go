@@ -1,13 +1,15 @@
 // Package sst provides facilities for modeling Semantic Spacetime
-// in an ArangoDB.
+// in an ArangoDB or another pluggable Backend.
 package sst
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
-	arango "github.com/arangodb/go-driver"
-	"github.com/arangodb/go-driver/http"
 	"github.com/pkg/errors"
 )
 
@@ -19,132 +21,170 @@ var (
 type Config struct {
 	// Associations, if specified, will override the default associations for this SST
 	Associations map[string]*Association
-	Name         string
-	// NodeCollections are the names of node collections to instantiate for this SST
+	// Backend, if specified, will store this SST instead of the default ArangoDB backend
+	Backend Backend
+	Name    string
+	// NodeCollections are the names of node collections to instantiate for
+	// this SST, added to the stored ones, see AddNodeCollection
 	NodeCollections []string
 	Password        string
-	URL             string
-	Username        string
+	// ReconcileNodeCollections, if true, removes the stored node collections
+	// not designated by NodeCollections that hold no nodes, see RemoveNodeCollection
+	ReconcileNodeCollections bool
+	// Schema, if specified, constrains the nodes and links of this SST
+	Schema   *Schema
+	URL      string
+	Username string
 }
 
+// SST is a Semantic Spacetime model. SST is safe for concurrent use by multiple
+// goroutines, see Timeline for how concurrently recorded events are ordered.
 type SST struct {
-	associations map[string]*Association
-	db           arango.Database
-	client       arango.Client
-	config       *Config
-	conn         arango.Connection
-	graph        arango.Graph
-	name         string
-
-	nodes map[string]arango.Collection
-
-	follows   arango.Collection
-	contains  arango.Collection
-	expresses arango.Collection
-	near      arango.Collection
+	backend Backend
+	config  *Config
+	events  *Timeline
 
-	prevEvents []*Node
+	// mu guards associations and timelines
+	mu           sync.RWMutex
+	associations map[string]*Association
+	timelines    map[string]*Timeline
 }
 
 var (
 	keyRegex = regexp.MustCompile(`[^a-zA-Z0-9_:.@()+,=;$!*'%-]`)
 )
 
-// Creates new Semantic Spacetime model backed by ArangoDB
+// Creates new Semantic Spacetime model backed by the configured Backend,
+// ArangoDB by default
 func NewSST(config *Config) (*SST, error) {
-	sst := &SST{
-		config: config,
-		name:   "semantic_spacetime",
-	}
+	return NewSSTContext(context.Background(), config)
+}
 
-	if config.Associations != nil {
-		sst.associations = config.Associations
-	} else {
-		sst.associations = make(map[string]*Association)
-		for k, v := range associations {
-			sst.associations[k] = v
+// NewSSTContext creates new Semantic Spacetime model using the provided context
+func NewSSTContext(ctx context.Context, config *Config) (*SST, error) {
+	if config.Schema != nil {
+		err := config.Schema.check(config.NodeCollections)
+		if err != nil {
+			return nil, err
 		}
 	}
-
-	conn, err := http.NewConnection(http.ConnectionConfig{
-		Endpoints: []string{config.URL},
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create ArangoDB connection")
-	}
-	sst.conn = conn
-	client, err := arango.NewClient(arango.ClientConfig{
-		Connection:     sst.conn,
-		Authentication: arango.BasicAuthentication(config.Username, config.Password),
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create ArangoDB client")
+	sst := &SST{
+		config:    config,
+		timelines: make(map[string]*Timeline),
 	}
-	sst.client = client
 
-	sst.db, err = sst.openDatabase(sst.config.Name)
-	if err == databaseDoesNotExist {
-		sst.db, err = sst.createDatabase(sst.config.Name)
-	}
-	if err != nil {
-		return nil, err
+	if config.Backend != nil {
+		sst.backend = config.Backend
+	} else {
+		sst.backend = &arangoBackend{}
 	}
-
-	exists, err := sst.db.GraphExists(context.TODO(), sst.name)
+	err := sst.backend.Open(ctx, config)
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		sst.graph, err = sst.db.Graph(context.TODO(), sst.name)
-		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to open graph: %v", sst.name)
-		}
-	} else {
-		sst.graph, err = sst.db.CreateGraph(context.TODO(), sst.name, &arango.CreateGraphOptions{
-			OrphanVertexCollections: []string{"Disconnected"},
-			EdgeDefinitions: []arango.EdgeDefinition{
-				{Collection: "Near", From: sst.config.NodeCollections, To: sst.config.NodeCollections},
-				{Collection: "Follows", From: sst.config.NodeCollections, To: sst.config.NodeCollections},
-				{Collection: "Contains", From: sst.config.NodeCollections, To: sst.config.NodeCollections},
-				{Collection: "Expresses", From: sst.config.NodeCollections, To: sst.config.NodeCollections},
-			},
-		})
+	if config.ReconcileNodeCollections {
+		err = sst.reconcileNodeCollections(ctx)
 		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to create graph: %v", sst.name)
+			return nil, err
 		}
 	}
 
-	sst.nodes = make(map[string]arango.Collection)
-	for _, kind := range sst.config.NodeCollections {
-		sst.nodes[kind], err = sst.graph.VertexCollection(context.TODO(), kind)
+	configured := config.Associations
+	if configured == nil {
+		configured = associations
+	}
+	err = sst.loadAssociations(ctx, configured)
+	if err != nil {
+		return nil, err
+	}
+	if config.Schema != nil && config.Schema.Backend {
+		err = sst.backend.ApplySchema(ctx, config.Schema, sst.Associations())
 		if err != nil {
-			return nil, errors.Wrapf(err, "sst: failed to create %v vertex collection", kind)
+			return nil, err
 		}
 	}
 
-	sst.near, _, err = sst.graph.EdgeCollection(nil, "Near")
+	sst.events, err = sst.TimelineContext(ctx, DefaultTimeline)
 	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create Near vertex collection")
+		return nil, err
 	}
-	sst.follows, _, err = sst.graph.EdgeCollection(nil, "Follows")
+
+	return sst, nil
+}
+
+// Close releases resources held by the Backend of this SST
+func (s *SST) Close() error {
+	return s.backend.Close()
+}
+
+// update executes fn within a Backend transaction, committing the writes of fn
+// if it succeeds and aborting them otherwise
+func (s *SST) update(ctx context.Context, fn func(tx BackendTx) error) error {
+	tx, err := s.backend.Begin(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create Follows vertex collection")
+		return err
 	}
-	sst.contains, _, err = sst.graph.EdgeCollection(nil, "Contains")
+	err = fn(tx)
 	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create Contains vertex collection")
+		abortErr := tx.Abort(ctx)
+		if abortErr != nil {
+			return errors.Wrapf(abortErr, "sst: failed to abort transaction after: %v", err)
+		}
+		return err
 	}
-	sst.expresses, _, err = sst.graph.EdgeCollection(nil, "Expresses")
-	if err != nil {
-		return nil, errors.Wrap(err, "sst: failed to create Expresses vertex collection")
+	return tx.Commit(ctx)
+}
+
+// ToDocumentKey encodes the name as a document key. Each byte of characters
+// disallowed in document keys, and of '%', is replaced with '%' followed by its
+// two hexadecimal digits, so that distinct names are encoded into distinct keys
+// and FromDocumentKey decodes the name from the key.
+func ToDocumentKey(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' || keyRegex.Match([]byte{c}) {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
 	}
+	return b.String()
+}
 
-	sst.prevEvents = []*Node{startEvent}
+// FromDocumentKey decodes the name encoded in the document key by ToDocumentKey.
+// A '%' not followed by two hexadecimal digits is kept as is, so that keys that
+// were not encoded by ToDocumentKey decode to themselves.
+func FromDocumentKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '%' && i+2 < len(key) && isHex(key[i+1]) && isHex(key[i+2]) {
+			c, _ := strconv.ParseUint(key[i+1:i+3], 16, 8)
+			b.WriteByte(byte(c))
+			i += 2
+			continue
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
 
-	return sst, nil
+// documentKey returns the key if it is made of characters allowed in document
+// keys, the key encoded by ToDocumentKey otherwise.
+func documentKey(key string) string {
+	if keyRegex.MatchString(key) {
+		return ToDocumentKey(key)
+	}
+	return key
 }
 
-// ToDocumentKey replaces disallowed characters in key names with '_'.
-func ToDocumentKey(s string) string {
+// identifierKey replaces disallowed characters in association keys and timeline
+// names with '_', keeping them compatible with stored associations and
+// timelines.
+func identifierKey(s string) string {
 	return keyRegex.ReplaceAllString(s, "_")
 }
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -1,7 +1,10 @@
 package sst
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,5 +15,95 @@ func TestThing(t *testing.T) {
 }
 
 func TestToDocumentKey(t *testing.T) {
-	assert.Equal(t, "Number_12345", ToDocumentKey("Number 12345"))
+	assert.Equal(t, "Number%2012345", ToDocumentKey("Number 12345"))
+	assert.Equal(t, "Number_12345", ToDocumentKey("Number_12345"))
+	assert.Equal(t, "100%25%20%C3%A9t%C3%A9", ToDocumentKey("100% été"))
+	for _, name := range []string{"Number 12345", "Number_12345", "100% été", "a/b?c#d", ""} {
+		assert.Equal(t, name, FromDocumentKey(ToDocumentKey(name)))
+	}
+	assert.Equal(t, "100%", FromDocumentKey("100%"))
+	assert.Equal(t, "%zz", FromDocumentKey("%zz"))
+}
+
+func TestDocumentKeys(t *testing.T) {
+	s := memorySST(t)
+	spaced := s.MustCreateNode("Node", "a b", nil, 1)
+	encoded := s.MustCreateNode("Node", "a%20b", nil, 1)
+	underscored := s.MustCreateNode("Node", "a_b", nil, 1)
+	slashed := s.MustCreateNode("Node", "a/b", nil, 1)
+	to := s.MustCreateNode("Node", "c", nil, 1)
+
+	for _, n := range []*Node{spaced, encoded, underscored, slashed} {
+		node, err := s.GetNode("Node/" + n.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, n, node)
+	}
+	kind, key := splitNodeID("Node/a/b")
+	assert.Equal(t, "Node", kind)
+	assert.Equal(t, "a/b", key)
+
+	keys := make(map[string]bool)
+	for _, n := range []*Node{spaced, encoded, underscored, slashed} {
+		keys[s.MustCreateLink(n, "related", to, nil, 1).Key] = true
+	}
+	assert.Len(t, keys, 4)
+	links, err := s.LinksTo(to)
+	assert.NoError(t, err)
+	assert.Len(t, links, 4)
+}
+
+func TestConcurrentUse(t *testing.T) {
+	const goroutines = 8
+	const events = 10
+	s := memorySST(t)
+	shared := s.MustTimeline("shared")
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			assert.NoError(t, s.CreateAssociation(&Association{Key: fmt.Sprintf("visited_%d", g), SemanticType: Follows, Fwd: "visited", Bwd: "was visited by", Nfwd: "did not visit", Nbwd: "was not visited by"}))
+			own := s.MustTimeline(fmt.Sprintf("journey-%d", g))
+			for e := 0; e < events; e++ {
+				evnt := own.MustNextEvent("Node", fmt.Sprintf("journey-%d-%d", g, e), nil)
+				shared.MustNextEvent("Node", fmt.Sprintf("shared-%d-%d", g, e), nil)
+				_, err := s.CreateLink(evnt, fmt.Sprintf("visited_%d", g), evnt, nil, 1)
+				assert.NoError(t, err)
+				assert.Len(t, s.MustTimeline("shared").PreviousEvents(), 1)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for g := 0; g < goroutines; g++ {
+		own := s.MustTimeline(fmt.Sprintf("journey-%d", g))
+		recorded, err := own.Events(time.Time{}, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, recorded, events)
+		assert.Equal(t, fmt.Sprintf("journey-%d-%d", g, events-1), own.PreviousEvents()[0].Key)
+	}
+
+	// Every shared event but the first follows exactly one other shared event
+	recorded, err := shared.Events(time.Time{}, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, recorded, goroutines*events)
+	starts := 0
+	for _, e := range recorded {
+		_, links, err := s.Neighbours(e, Follows, Inbound, 1)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(links), 1)
+		if len(links) == 0 {
+			starts++
+		}
+	}
+	assert.Equal(t, 1, starts)
+}
+
+func TestAssociations(t *testing.T) {
+	s := memorySST(t)
+	listed := s.Associations()
+	assert.Len(t, listed, len(associations))
+	assert.Equal(t, "alias", listed[0].Key)
+	listed[0].Fwd = "changed"
+	assert.Equal(t, "also known as", s.association("alias").Fwd)
 }
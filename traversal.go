@@ -0,0 +1,117 @@
+package sst
+
+import (
+	"context"
+)
+
+// Direction designates which links of a node to follow
+type Direction int
+
+const (
+	// Outbound follows links from the node
+	Outbound Direction = iota
+	// Inbound follows links to the node
+	Inbound
+	// Any follows links in both directions
+	Any
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Outbound:
+		return "outbound"
+	case Inbound:
+		return "inbound"
+	case Any:
+		return "any"
+	}
+	return "unknown"
+}
+
+// reverse returns the opposite direction
+func (d Direction) reverse() Direction {
+	switch d {
+	case Outbound:
+		return Inbound
+	case Inbound:
+		return Outbound
+	}
+	return d
+}
+
+// TraversalOptions designate the links walked by a traversal. Nil
+// TraversalOptions walk the non-negated links of all SemanticTypes in Any
+// direction, one link away from the start node.
+type TraversalOptions struct {
+	// SemanticTypes of links to walk, all if none are designated. A negative
+	// SemanticType walks links of its positive counterpart in the opposite
+	// direction, e.g. -Contains walks Contains links inbound when walking outbound.
+	SemanticTypes []SemanticType
+	// Direction to walk links in
+	Direction Direction
+	// Depth is the maximum number of links away from the start node
+	Depth int
+	// IncludeNegated walks negated links as well, negated links are skipped otherwise
+	IncludeNegated bool
+}
+
+// Neighbours returns nodes up to depth links away from the node and the links
+// walked to reach them. Only non-negated links of the designated SemanticType are
+// walked in the designated direction. A negative SemanticType walks links of its
+// positive counterpart in the opposite direction.
+func (s *SST) Neighbours(node *Node, sType SemanticType, dir Direction, depth int) ([]*Node, []*Link, error) {
+	return s.NeighboursContext(context.Background(), node, sType, dir, depth)
+}
+
+// NeighboursContext invokes Neighbours using the provided context
+func (s *SST) NeighboursContext(ctx context.Context, node *Node, sType SemanticType, dir Direction, depth int) ([]*Node, []*Link, error) {
+	return s.TraverseContext(ctx, node, &TraversalOptions{
+		SemanticTypes: []SemanticType{sType},
+		Direction:     dir,
+		Depth:         depth,
+	})
+}
+
+// Traverse returns nodes reached by walking links designated by opts from the node
+// and the links walked to reach them. Nil opts designate the default TraversalOptions.
+func (s *SST) Traverse(node *Node, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	return s.TraverseContext(context.Background(), node, opts)
+}
+
+// TraverseContext invokes Traverse using the provided context
+func (s *SST) TraverseContext(ctx context.Context, node *Node, opts *TraversalOptions) ([]*Node, []*Link, error) {
+	id, err := NodeID(node)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.backend.Traverse(ctx, id, opts)
+}
+
+// traversalOptions returns the options, or the defaults if nil
+func traversalOptions(opts *TraversalOptions) *TraversalOptions {
+	if opts == nil {
+		return &TraversalOptions{Direction: Any, Depth: 1}
+	}
+	return opts
+}
+
+// walkedDirections determines the direction each link collection is walked in
+func walkedDirections(opts *TraversalOptions) map[SemanticType]Direction {
+	opts = traversalOptions(opts)
+	types := opts.SemanticTypes
+	if len(types) == 0 {
+		types = linkTypes(nil)
+	}
+	dirs := make(map[SemanticType]Direction)
+	for _, typ := range types {
+		dir := opts.Direction
+		if typ < 0 {
+			dir = dir.reverse()
+		}
+		if walked, ok := dirs[typ.abs()]; ok && walked != dir {
+			dir = Any
+		}
+		dirs[typ.abs()] = dir
+	}
+	return dirs
+}
@@ -0,0 +1,61 @@
+package sst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeighbours(t *testing.T) {
+	s := memorySST(t)
+	france := s.MustCreateNode("Node", "France", nil, 1)
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	louvre := s.MustCreateNode("Node", "Louvre", nil, 1)
+	s.MustCreateLink(france, "contains", paris, nil, 1)
+	s.MustCreateLink(paris, "contains", louvre, nil, 1)
+	s.MustCreateLink(paris, "is_like", louvre, nil, 1)
+
+	nodes, links, err := s.Neighbours(france, Contains, Outbound, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{paris}, nodes)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "+Node%2FFrancecontainsNode%2FParis", links[0].Key)
+
+	nodes, _, err = s.Neighbours(france, Contains, Outbound, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{paris, louvre}, nodes)
+
+	nodes, _, err = s.Neighbours(louvre, -Contains, Outbound, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{paris, france}, nodes)
+
+	nodes, links, err = s.Neighbours(louvre, Near, Any, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{paris}, nodes)
+	assert.Equal(t, "is_like", links[0].SID)
+}
+
+func TestTraverseNegated(t *testing.T) {
+	s := memorySST(t)
+	n1 := s.MustCreateNode("Node", "n1", nil, 1)
+	n2 := s.MustCreateNode("Node", "n2", nil, 1)
+	n3 := s.MustCreateNode("Node", "n3", nil, 1)
+	s.MustBlockLink(n1, "contains", n2, nil, 1)
+	s.MustCreateLink(n2, "contains", n3, nil, 1)
+
+	nodes, links, err := s.Traverse(n1, &TraversalOptions{Direction: Outbound, Depth: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+	assert.Empty(t, links)
+
+	nodes, links, err = s.Traverse(n1, &TraversalOptions{Direction: Outbound, Depth: 2, IncludeNegated: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{n2, n3}, nodes)
+	assert.Len(t, links, 2)
+	assert.True(t, MustLinkNegated(links[0]))
+
+	nodes, links, err = s.Traverse(n3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Node{n2}, nodes)
+	assert.Len(t, links, 1)
+}
@@ -0,0 +1,172 @@
+package sst
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	invalidTypedData = errors.New("sst: typed data must encode to a JSON object")
+)
+
+// TypedNode is a Node whose Data is decoded into a value of type T
+type TypedNode[T any] struct {
+	*Node
+	// Value is the decoded Data of the node
+	Value T
+}
+
+// TypedLink is a Link whose Data is decoded into a value of type T
+type TypedLink[T any] struct {
+	*Link
+	// Value is the decoded Data of the link
+	Value T
+}
+
+// ToData encodes the value into Data the way encoding/json encodes it, so that
+// struct fields are named after their json tags. The value must encode to a JSON
+// object. A value encoding to an empty object is encoded into nil Data.
+func ToData(value interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "sst: failed to encode typed data")
+	}
+	var data map[string]interface{}
+	err = json.Unmarshal(encoded, &data)
+	if err != nil {
+		return nil, errors.Wrapf(invalidTypedData, "sst: cannot encode: %T", value)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// FromData decodes the Data into the value pointed to by v the way encoding/json
+// decodes it. Data entries without a matching field are ignored and fields
+// without a matching entry are left unchanged, so that documents stored before
+// a field was added or removed still decode.
+func FromData(data map[string]interface{}, v interface{}) error {
+	if data == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to decode typed data")
+	}
+	err = json.Unmarshal(encoded, v)
+	if err != nil {
+		return errors.Wrap(err, "sst: failed to decode typed data")
+	}
+	return nil
+}
+
+// NodeValue decodes the Data of the node into a value of type T
+func NodeValue[T any](node *Node) (T, error) {
+	var value T
+	err := FromData(node.Data, &value)
+	return value, err
+}
+
+// LinkValue decodes the Data of the link into a value of type T
+func LinkValue[T any](link *Link) (T, error) {
+	var value T
+	err := FromData(link.Data, &value)
+	return value, err
+}
+
+// CreateTypedNode idempotently creates a node of the specified kind with the
+// value encoded into its Data by ToData, see CreateNode.
+func CreateTypedNode[T any](s *SST, kind, key string, value T, weight float64) (*TypedNode[T], error) {
+	return CreateTypedNodeContext(context.Background(), s, kind, key, value, weight)
+}
+
+// CreateTypedNodeContext invokes CreateTypedNode using the provided context
+func CreateTypedNodeContext[T any](ctx context.Context, s *SST, kind, key string, value T, weight float64) (*TypedNode[T], error) {
+	data, err := ToData(value)
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.CreateNodeContext(ctx, kind, key, data, weight)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedNode[T]{Node: node, Value: value}, nil
+}
+
+// MustCreateTypedNode invokes CreateTypedNode, but panics on error
+func MustCreateTypedNode[T any](s *SST, kind, key string, value T, weight float64) *TypedNode[T] {
+	node, err := CreateTypedNode(s, kind, key, value, weight)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// GetTypedNode retrieves the node for the designated _id with its Data decoded
+// into a value of type T, see GetNode.
+func GetTypedNode[T any](s *SST, id string) (*TypedNode[T], error) {
+	return GetTypedNodeContext[T](context.Background(), s, id)
+}
+
+// GetTypedNodeContext invokes GetTypedNode using the provided context
+func GetTypedNodeContext[T any](ctx context.Context, s *SST, id string) (*TypedNode[T], error) {
+	node, err := s.GetNodeContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	value, err := NodeValue[T](node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to get node for key: %v", id)
+	}
+	return &TypedNode[T]{Node: node, Value: value}, nil
+}
+
+// CreateTypedLink creates the link with the value encoded into its Data by
+// ToData, or updates the existing link, see CreateLink.
+func CreateTypedLink[T any](s *SST, from *Node, rel string, to *Node, value T, weight float64) (*TypedLink[T], error) {
+	return CreateTypedLinkContext(context.Background(), s, from, rel, to, value, weight)
+}
+
+// CreateTypedLinkContext invokes CreateTypedLink using the provided context
+func CreateTypedLinkContext[T any](ctx context.Context, s *SST, from *Node, rel string, to *Node, value T, weight float64) (*TypedLink[T], error) {
+	data, err := ToData(value)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.CreateLinkContext(ctx, from, rel, to, data, weight)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedLink[T]{Link: link, Value: value}, nil
+}
+
+// MustCreateTypedLink invokes CreateTypedLink, but panics on error
+func MustCreateTypedLink[T any](s *SST, from *Node, rel string, to *Node, value T, weight float64) *TypedLink[T] {
+	link, err := CreateTypedLink(s, from, rel, to, value, weight)
+	if err != nil {
+		panic(err)
+	}
+	return link
+}
+
+// GetTypedLink retrieves the link, or its negation if negate is true, with its
+// Data decoded into a value of type T, see GetLink.
+func GetTypedLink[T any](s *SST, from *Node, rel string, to *Node, negate bool) (*TypedLink[T], error) {
+	return GetTypedLinkContext[T](context.Background(), s, from, rel, to, negate)
+}
+
+// GetTypedLinkContext invokes GetTypedLink using the provided context
+func GetTypedLinkContext[T any](ctx context.Context, s *SST, from *Node, rel string, to *Node, negate bool) (*TypedLink[T], error) {
+	link, err := s.GetLinkContext(ctx, from, rel, to, negate)
+	if err != nil {
+		return nil, err
+	}
+	value, err := LinkValue[T](link)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sst: failed to get link: %v", link.Key)
+	}
+	return &TypedLink[T]{Link: link, Value: value}, nil
+}
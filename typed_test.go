@@ -0,0 +1,50 @@
+package sst
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type person struct {
+	Description string   `json:"description"`
+	Number      int      `json:"number,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+type visit struct {
+	Days int `json:"days"`
+}
+
+func TestTypedNodesAndLinks(t *testing.T) {
+	s := memorySST(t)
+	mark := MustCreateTypedNode(s, "Node", "Mark", &person{Description: "Professor", Number: 123456}, 1)
+	assert.Equal(t, map[string]interface{}{"description": "Professor", "number": 123456.0}, mark.Data)
+
+	got, err := GetTypedNode[person](s, "Node/Mark")
+	assert.NoError(t, err)
+	assert.Equal(t, person{Description: "Professor", Number: 123456}, got.Value)
+	assert.Equal(t, "Node/", got.Prefix)
+
+	legacy := s.MustCreateNode("Node", "Emily", map[string]interface{}{"description": "Engineer", "number": 7.0, "extra": true}, 1)
+	emily, err := NodeValue[person](legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, person{Description: "Engineer", Number: 7}, emily)
+
+	empty := MustCreateTypedNode(s, "Node", "Nobody", struct{}{}, 1)
+	assert.Nil(t, empty.Data)
+	_, err = CreateTypedNode(s, "Node", "Number", 42, 1)
+	assert.Equal(t, invalidTypedData, errors.Cause(err))
+	_, err = GetTypedNode[visit](s, "Node/Mark")
+	assert.NoError(t, err)
+	_, err = GetTypedNode[[]string](s, "Node/Mark")
+	assert.Error(t, err)
+
+	paris := s.MustCreateNode("Node", "Paris", nil, 1)
+	MustCreateTypedLink(s, mark.Node, "related", paris, visit{Days: 3}, 1)
+	link, err := GetTypedLink[visit](s, mark.Node, "related", paris, false)
+	assert.NoError(t, err)
+	assert.Equal(t, visit{Days: 3}, link.Value)
+	assert.Equal(t, "related", link.Association.Key)
+}